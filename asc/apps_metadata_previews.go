@@ -23,6 +23,7 @@ package asc
 import (
 	"context"
 	"fmt"
+	"io"
 )
 
 // PreviewType defines model for PreviewType.
@@ -189,11 +190,15 @@ func (s *AppsService) GetAppPreview(ctx context.Context, id string, params *GetA
 //
 // https://developer.apple.com/documentation/appstoreconnectapi/create_an_app_preview
 func (s *AppsService) CreateAppPreview(ctx context.Context, fileName string, fileSize int64, appPreviewSetID string) (*AppPreviewResponse, *Response, error) {
+	return s.createAppPreview(ctx, appPreviewCreateRequestAttributes{
+		FileName: fileName,
+		FileSize: fileSize,
+	}, appPreviewSetID)
+}
+
+func (s *AppsService) createAppPreview(ctx context.Context, attributes appPreviewCreateRequestAttributes, appPreviewSetID string) (*AppPreviewResponse, *Response, error) {
 	req := appPreviewCreateRequest{
-		Attributes: appPreviewCreateRequestAttributes{
-			FileName: fileName,
-			FileSize: fileSize,
-		},
+		Attributes: attributes,
 		Relationships: appPreviewCreateRequestRelationships{
 			AppPreviewSet: relationshipDeclaration{
 				Data: RelationshipData{
@@ -242,3 +247,67 @@ func (s *AppsService) DeleteAppPreview(ctx context.Context, id string) (*Respons
 
 	return s.client.delete(ctx, url, nil)
 }
+
+// UploadPreview uploads a video file as a new preview in a preview set. It works like
+// UploadScreenshot, and additionally sends the MIME type of the file, as detected by
+// DetectMimeType, when reserving the preview. If previewFrameTimeCode is not nil, it chooses the
+// preview's poster frame when the preview is committed. See PreviewFrameTimeCode.
+//
+// https://developer.apple.com/documentation/appstoreconnectapi/uploading_assets_to_app_store_connect
+func (s *AppsService) UploadPreview(ctx context.Context, appPreviewSetID string, fileName string, file io.ReaderAt, fileSize int64, previewFrameTimeCode *string) (*AppPreview, error) {
+	kind := s.previewAsset(appPreviewSetID, fileName, fileSize, DetectMimeType(fileName, file), previewFrameTimeCode)
+
+	return uploadAsset(ctx, s.client, kind, file, fileSize)
+}
+
+// previewAsset describes how UploadPreview reserves, commits, reads and deletes an app preview.
+func (s *AppsService) previewAsset(appPreviewSetID string, fileName string, fileSize int64, mimeType string, previewFrameTimeCode *string) assetKind[AppPreview] {
+	return assetKind[AppPreview]{
+		reserve: func(ctx context.Context) (AppPreview, error) {
+			res, _, err := s.createAppPreview(ctx, appPreviewCreateRequestAttributes{
+				FileName: fileName,
+				FileSize: fileSize,
+				MimeType: String(mimeType),
+			}, appPreviewSetID)
+			if err != nil {
+				return AppPreview{}, err
+			}
+
+			return res.Data, nil
+		},
+		commit: func(ctx context.Context, id string, checksum string) error {
+			_, _, err := s.CommitAppPreview(ctx, id, Bool(true), &checksum, previewFrameTimeCode)
+
+			return err
+		},
+		get: func(ctx context.Context, id string) (AppPreview, error) {
+			res, _, err := s.GetAppPreview(ctx, id, nil)
+			if err != nil {
+				return AppPreview{}, err
+			}
+
+			return res.Data, nil
+		},
+		remove: s.DeleteAppPreview,
+	}
+}
+
+func (a AppPreview) assetID() string {
+	return a.ID
+}
+
+func (a AppPreview) uploadOperations() []UploadOperation {
+	if a.Attributes == nil {
+		return nil
+	}
+
+	return a.Attributes.UploadOperations
+}
+
+func (a AppPreview) deliveryState() *AppMediaAssetState {
+	if a.Attributes == nil {
+		return nil
+	}
+
+	return a.Attributes.AssetDeliveryState
+}
@@ -22,7 +22,11 @@ package asc
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetAppPreview(t *testing.T) {
@@ -56,3 +60,33 @@ func TestDeleteAppPreview(t *testing.T) {
 		return client.Apps.DeleteAppPreview(ctx, "10")
 	})
 }
+
+func TestUploadPreview(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "appPreviews", AppMediaAssetStateComplete)
+
+	preview, err := client.Apps.UploadPreview(context.Background(), "10", "preview.mov", strings.NewReader("contents"), 8, String("00:00:05:00"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", preview.ID)
+	assert.Equal(t, "contents", string(server.uploaded))
+	assert.Equal(t, "video/quicktime", server.createAttributes()["mimeType"])
+	assert.Equal(t, "98bf7d8c15784f0a3d63204441e1e2aa", server.commitAttributes()["sourceFileChecksum"])
+	assert.Equal(t, "00:00:05:00", server.commitAttributes()["previewFrameTimeCode"])
+	assert.False(t, server.deleted)
+}
+
+func TestUploadPreviewDeliveryFailed(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "appPreviews", AppMediaAssetStateFailed)
+
+	_, err := client.Apps.UploadPreview(context.Background(), "10", "preview.mp4", strings.NewReader("contents"), 8, nil)
+
+	var deliveryErr ErrAssetDelivery
+
+	assert.True(t, errors.As(err, &deliveryErr))
+	assert.Nil(t, server.commitAttributes()["previewFrameTimeCode"])
+	assert.True(t, server.deleted)
+}
@@ -23,6 +23,7 @@ package asc
 import (
 	"context"
 	"fmt"
+	"io"
 )
 
 // RoutingAppCoverage defines model for RoutingAppCoverage.
@@ -112,6 +113,18 @@ type AppMediaAssetState struct {
 	Warnings []AppMediaStateError `json:"warnings,omitempty"`
 }
 
+// Possible values of AppMediaAssetState.State.
+const (
+	// AppMediaAssetStateAwaitingUpload is the state of an asset that has been reserved, but not uploaded yet.
+	AppMediaAssetStateAwaitingUpload = "AWAITING_UPLOAD"
+	// AppMediaAssetStateUploadComplete is the state of an asset that has been committed, but not processed yet.
+	AppMediaAssetStateUploadComplete = "UPLOAD_COMPLETE"
+	// AppMediaAssetStateComplete is the state of an asset that has been processed successfully.
+	AppMediaAssetStateComplete = "COMPLETE"
+	// AppMediaAssetStateFailed is the state of an asset that App Store Connect failed to process.
+	AppMediaAssetStateFailed = "FAILED"
+)
+
 // AppMediaStateError defines model for AppMediaStateError.
 //
 // https://developer.apple.com/documentation/appstoreconnectapi/appmediaassetstate
@@ -120,6 +133,21 @@ type AppMediaStateError struct {
 	Description *string `json:"description,omitempty"`
 }
 
+// Error formats the code and description of the error, such as
+// "IMAGE_INCORRECT_DIMENSIONS: The dimensions of the image are wrong.".
+func (e AppMediaStateError) Error() string {
+	var code, description string
+	if e.Code != nil {
+		code = *e.Code
+	}
+
+	if e.Description != nil {
+		description = *e.Description
+	}
+
+	return fmt.Sprintf("%s: %s", code, description)
+}
+
 // GetRoutingAppCoverageForVersionQuery are query options for GetRoutingAppCoverageForVersion
 //
 // https://developer.apple.com/documentation/appstoreconnectapi/read_the_routing_app_coverage_information_of_an_app_store_version
@@ -213,3 +241,60 @@ func (s *AppsService) DeleteRoutingAppCoverage(ctx context.Context, id string) (
 
 	return s.client.delete(ctx, url, nil)
 }
+
+// UploadRoutingAppCoverage uploads a GeoJSON file as the routing app coverage file of an App Store
+// version. It works like AppsService.UploadScreenshot.
+//
+// https://developer.apple.com/documentation/appstoreconnectapi/uploading_assets_to_app_store_connect
+func (s *AppsService) UploadRoutingAppCoverage(ctx context.Context, appStoreVersionID string, fileName string, file io.ReaderAt, fileSize int64) (*RoutingAppCoverage, error) {
+	return uploadAsset(ctx, s.client, s.routingAppCoverageAsset(appStoreVersionID, fileName, fileSize), file, fileSize)
+}
+
+// routingAppCoverageAsset describes how UploadRoutingAppCoverage reserves, commits, reads and
+// deletes a routing app coverage file.
+func (s *AppsService) routingAppCoverageAsset(appStoreVersionID string, fileName string, fileSize int64) assetKind[RoutingAppCoverage] {
+	return assetKind[RoutingAppCoverage]{
+		reserve: func(ctx context.Context) (RoutingAppCoverage, error) {
+			res, _, err := s.CreateRoutingAppCoverage(ctx, fileName, fileSize, appStoreVersionID)
+			if err != nil {
+				return RoutingAppCoverage{}, err
+			}
+
+			return res.Data, nil
+		},
+		commit: func(ctx context.Context, id string, checksum string) error {
+			_, _, err := s.CommitRoutingAppCoverage(ctx, id, Bool(true), &checksum)
+
+			return err
+		},
+		get: func(ctx context.Context, id string) (RoutingAppCoverage, error) {
+			res, _, err := s.GetRoutingAppCoverage(ctx, id, nil)
+			if err != nil {
+				return RoutingAppCoverage{}, err
+			}
+
+			return res.Data, nil
+		},
+		remove: s.DeleteRoutingAppCoverage,
+	}
+}
+
+func (a RoutingAppCoverage) assetID() string {
+	return a.ID
+}
+
+func (a RoutingAppCoverage) uploadOperations() []UploadOperation {
+	if a.Attributes == nil {
+		return nil
+	}
+
+	return a.Attributes.UploadOperations
+}
+
+func (a RoutingAppCoverage) deliveryState() *AppMediaAssetState {
+	if a.Attributes == nil {
+		return nil
+	}
+
+	return a.Attributes.AssetDeliveryState
+}
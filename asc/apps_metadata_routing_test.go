@@ -22,7 +22,11 @@ package asc // nolint: dupl
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetRoutingAppCoverageForAppStoreVersion(t *testing.T) {
@@ -64,3 +68,30 @@ func TestDeleteRoutingAppCoverage(t *testing.T) {
 		return client.Apps.DeleteRoutingAppCoverage(ctx, "10")
 	})
 }
+
+func TestUploadRoutingAppCoverage(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "routingAppCoverages", AppMediaAssetStateComplete)
+
+	coverage, err := client.Apps.UploadRoutingAppCoverage(context.Background(), "10", "coverage.geojson", strings.NewReader("contents"), 8)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", coverage.ID)
+	assert.Equal(t, "contents", string(server.uploaded))
+	assert.Equal(t, "98bf7d8c15784f0a3d63204441e1e2aa", server.commitAttributes()["sourceFileChecksum"])
+	assert.False(t, server.deleted)
+}
+
+func TestUploadRoutingAppCoverageDeliveryFailed(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "routingAppCoverages", AppMediaAssetStateFailed)
+
+	_, err := client.Apps.UploadRoutingAppCoverage(context.Background(), "10", "coverage.geojson", strings.NewReader("contents"), 8)
+
+	var deliveryErr ErrAssetDelivery
+
+	assert.True(t, errors.As(err, &deliveryErr))
+	assert.True(t, server.deleted)
+}
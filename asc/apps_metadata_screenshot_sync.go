@@ -0,0 +1,625 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register the JPEG decoder for image.DecodeConfig
+	_ "image/png"  // register the PNG decoder for image.DecodeConfig
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownLocale happens when a screenshot directory is named after a locale that the
+// App Store version has no localization for.
+type ErrUnknownLocale struct {
+	Locale string
+}
+
+func (e ErrUnknownLocale) Error() string {
+	return fmt.Sprintf("app store version has no localization for locale %s", e.Locale)
+}
+
+// ErrUnknownScreenshotSize happens when the display type of a screenshot cannot be inferred
+// from its dimensions. Move the file into a directory named after its display type instead.
+type ErrUnknownScreenshotSize struct {
+	Path   string
+	Width  int
+	Height int
+}
+
+func (e ErrUnknownScreenshotSize) Error() string {
+	return fmt.Sprintf("could not infer the display type of %s from its size %dx%d", e.Path, e.Width, e.Height)
+}
+
+// screenshotDisplayTypes are the display types a screenshot directory can be named after.
+var screenshotDisplayTypes = map[ScreenshotDisplayType]bool{
+	ScreenshotDisplayTypeAppAppleTV:                true,
+	ScreenshotDisplayTypeAppDesktop:                true,
+	ScreenshotDisplayTypeAppiPad105:                true,
+	ScreenshotDisplayTypeAppiPad97:                 true,
+	ScreenshotDisplayTypeAppiPadPro129:             true,
+	ScreenshotDisplayTypeAppiPadPro3Gen11:          true,
+	ScreenshotDisplayTypeAppiPadPro3Gen129:         true,
+	ScreenshotDisplayTypeAppiPhone35:               true,
+	ScreenshotDisplayTypeAppiPhone40:               true,
+	ScreenshotDisplayTypeAppiPhone47:               true,
+	ScreenshotDisplayTypeAppiPhone55:               true,
+	ScreenshotDisplayTypeAppiPhone58:               true,
+	ScreenshotDisplayTypeAppiPhone65:               true,
+	ScreenshotDisplayTypeAppWatchSeries3:           true,
+	ScreenshotDisplayTypeAppWatchSeries4:           true,
+	ScreenshotDisplayTypeiMessageAppIPad105:        true,
+	ScreenshotDisplayTypeiMessageAppIPad97:         true,
+	ScreenshotDisplayTypeiMessageAppIPadPro129:     true,
+	ScreenshotDisplayTypeiMessageAppIPadPro3Gen11:  true,
+	ScreenshotDisplayTypeiMessageAppIPadPro3Gen129: true,
+	ScreenshotDisplayTypeiMessageAppIPhone40:       true,
+	ScreenshotDisplayTypeiMessageAppIPhone47:       true,
+	ScreenshotDisplayTypeiMessageAppIPhone55:       true,
+	ScreenshotDisplayTypeiMessageAppIPhone58:       true,
+	ScreenshotDisplayTypeiMessageAppIPhone65:       true,
+}
+
+// screenshotSizes maps the portrait dimensions of each accepted screenshot size to the
+// display type it belongs to. iMessage display types share their sizes with the app
+// display types, so they can only be chosen by directory name.
+var screenshotSizes = map[[2]int]ScreenshotDisplayType{
+	{1242, 2688}: ScreenshotDisplayTypeAppiPhone65,
+	{1284, 2778}: ScreenshotDisplayTypeAppiPhone65,
+	{1125, 2436}: ScreenshotDisplayTypeAppiPhone58,
+	{1242, 2208}: ScreenshotDisplayTypeAppiPhone55,
+	{750, 1334}:  ScreenshotDisplayTypeAppiPhone47,
+	{640, 1136}:  ScreenshotDisplayTypeAppiPhone40,
+	{640, 1096}:  ScreenshotDisplayTypeAppiPhone40,
+	{640, 960}:   ScreenshotDisplayTypeAppiPhone35,
+	{640, 920}:   ScreenshotDisplayTypeAppiPhone35,
+	{2048, 2732}: ScreenshotDisplayTypeAppiPadPro3Gen129,
+	{1668, 2388}: ScreenshotDisplayTypeAppiPadPro3Gen11,
+	{1668, 2224}: ScreenshotDisplayTypeAppiPad105,
+	{1536, 2048}: ScreenshotDisplayTypeAppiPad97,
+	{768, 1024}:  ScreenshotDisplayTypeAppiPad97,
+	{800, 1280}:  ScreenshotDisplayTypeAppDesktop,
+	{900, 1440}:  ScreenshotDisplayTypeAppDesktop,
+	{1600, 2560}: ScreenshotDisplayTypeAppDesktop,
+	{1800, 2880}: ScreenshotDisplayTypeAppDesktop,
+	{1080, 1920}: ScreenshotDisplayTypeAppAppleTV,
+	{2160, 3840}: ScreenshotDisplayTypeAppAppleTV,
+	{368, 448}:   ScreenshotDisplayTypeAppWatchSeries4,
+	{312, 390}:   ScreenshotDisplayTypeAppWatchSeries3,
+}
+
+// InferScreenshotDisplayType returns the display type of a screenshot with the given
+// dimensions, in either orientation. It returns false if the size is not one that App Store
+// Connect accepts.
+func InferScreenshotDisplayType(width, height int) (ScreenshotDisplayType, bool) {
+	if width > height {
+		width, height = height, width
+	}
+
+	displayType, ok := screenshotSizes[[2]int{width, height}]
+
+	return displayType, ok
+}
+
+// LocalScreenshot is a screenshot file found in a local screenshot directory.
+type LocalScreenshot struct {
+	Path     string
+	FileName string
+	FileSize int64
+	Checksum string
+}
+
+// ScreenshotSetPlan describes the changes needed to make one screenshot set match a local
+// directory.
+type ScreenshotSetPlan struct {
+	Locale         string
+	LocalizationID string
+	DisplayType    ScreenshotDisplayType
+	// SetID is the ID of the existing screenshot set, or empty if the set will be created.
+	SetID string
+	// Delete is true if the set has no local counterpart and will be deleted.
+	Delete bool
+	// Add are the local screenshots that will be uploaded.
+	Add []LocalScreenshot
+	// Remove are the existing screenshots that will be deleted.
+	Remove []AppScreenshot
+	// Reorder is true if the screenshots will be reordered after uploading, so that they
+	// follow the order of the local file names.
+	Reorder bool
+
+	// order is the desired order of the set, where each entry is either the ID of an existing
+	// screenshot or the checksum of a local screenshot that will be added.
+	order []string
+}
+
+// HasChanges reports whether applying the set plan would change anything.
+func (p ScreenshotSetPlan) HasChanges() bool {
+	return p.SetID == "" || p.Delete || len(p.Add) > 0 || len(p.Remove) > 0 || p.Reorder
+}
+
+// ScreenshotSyncPlan describes the changes needed to make the screenshots of an App Store
+// version match a local directory tree. It is returned by AppsService.PlanScreenshotSync and
+// applied with AppsService.ApplyScreenshotSync.
+type ScreenshotSyncPlan struct {
+	Sets []ScreenshotSetPlan
+}
+
+// HasChanges reports whether applying the plan would change anything.
+func (p *ScreenshotSyncPlan) HasChanges() bool {
+	for _, set := range p.Sets {
+		if set.HasChanges() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String formats the plan as one line per change, suitable for a dry run.
+func (p *ScreenshotSyncPlan) String() string {
+	var b strings.Builder
+
+	for _, set := range p.Sets {
+		prefix := fmt.Sprintf("%s %s:", set.Locale, set.DisplayType)
+
+		switch {
+		case set.Delete:
+			fmt.Fprintf(&b, "%s delete set %s\n", prefix, set.SetID)
+
+			continue
+		case set.SetID == "":
+			fmt.Fprintf(&b, "%s create set\n", prefix)
+		}
+
+		for _, screenshot := range set.Remove {
+			fmt.Fprintf(&b, "%s - %s (%s)\n", prefix, screenshotFileName(screenshot), screenshot.ID)
+		}
+
+		for _, screenshot := range set.Add {
+			fmt.Fprintf(&b, "%s + %s\n", prefix, screenshot.FileName)
+		}
+
+		if set.Reorder {
+			fmt.Fprintf(&b, "%s reorder\n", prefix)
+		}
+	}
+
+	return b.String()
+}
+
+// SyncScreenshots makes the screenshots of an App Store version match a local directory tree,
+// and returns the plan that was applied. If dryRun is true, the plan is returned without
+// being applied. See PlanScreenshotSync for the layout of the directory tree.
+func (s *AppsService) SyncScreenshots(ctx context.Context, appStoreVersionID string, dir string, dryRun bool) (*ScreenshotSyncPlan, error) {
+	plan, err := s.PlanScreenshotSync(ctx, appStoreVersionID, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return plan, nil
+	}
+
+	return plan, s.ApplyScreenshotSync(ctx, plan)
+}
+
+// PlanScreenshotSync compares the screenshots of an App Store version with a local directory
+// tree, without changing anything.
+//
+// The tree has one directory per locale, such as "en-US", in the style of fastlane. Screenshots
+// directly inside a locale directory have their display type inferred from their dimensions,
+// while screenshots in a subdirectory named after a display type, such as
+// "IMESSAGE_APP_IPHONE_65", belong to that display type. Screenshots are ordered by file name.
+//
+// Existing screenshots are matched with local files by their source file checksum. For every
+// locale in the tree, unmatched existing screenshots are removed, unmatched local files are
+// added, and screenshot sets with no local screenshots are deleted. Localizations without a
+// directory are left untouched.
+func (s *AppsService) PlanScreenshotSync(ctx context.Context, appStoreVersionID string, dir string) (*ScreenshotSyncPlan, error) {
+	local, err := readScreenshotTree(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	localizations, _, err := NewPager[AppStoreVersionLocalization](s.client, func(ctx context.Context) (*AppStoreVersionLocalizationsResponse, *Response, error) {
+		return s.ListLocalizationsForAppStoreVersion(ctx, appStoreVersionID, &ListLocalizationsForAppStoreVersionQuery{Limit: 200})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	localizationIDs := make(map[string]string)
+
+	for _, localization := range localizations.Data {
+		if localization.Attributes != nil && localization.Attributes.Locale != nil {
+			localizationIDs[*localization.Attributes.Locale] = localization.ID
+		}
+	}
+
+	locales := make([]string, 0, len(local))
+	for locale := range local {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+
+	plan := new(ScreenshotSyncPlan)
+
+	for _, locale := range locales {
+		localizationID, ok := localizationIDs[locale]
+		if !ok {
+			return nil, ErrUnknownLocale{Locale: locale}
+		}
+
+		sets, err := s.planLocalizationScreenshots(ctx, locale, localizationID, local[locale])
+		if err != nil {
+			return nil, err
+		}
+
+		plan.Sets = append(plan.Sets, sets...)
+	}
+
+	return plan, nil
+}
+
+func (s *AppsService) planLocalizationScreenshots(ctx context.Context, locale string, localizationID string, local map[ScreenshotDisplayType][]LocalScreenshot) ([]ScreenshotSetPlan, error) {
+	sets, _, err := NewPager[AppScreenshotSet](s.client, func(ctx context.Context) (*AppScreenshotSetsResponse, *Response, error) {
+		return s.ListAppScreenshotSetsForAppStoreVersionLocalization(ctx, localizationID, &ListAppScreenshotSetsForAppStoreVersionLocalizationQuery{Limit: 200})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make(map[ScreenshotDisplayType]AppScreenshotSet)
+
+	for _, set := range sets.Data {
+		if set.Attributes != nil && set.Attributes.ScreenshotDisplayType != nil {
+			remote[*set.Attributes.ScreenshotDisplayType] = set
+		}
+	}
+
+	displayTypes := make([]ScreenshotDisplayType, 0, len(local)+len(remote))
+
+	for displayType := range local {
+		displayTypes = append(displayTypes, displayType)
+	}
+
+	for displayType := range remote {
+		if _, ok := local[displayType]; !ok {
+			displayTypes = append(displayTypes, displayType)
+		}
+	}
+
+	sort.Slice(displayTypes, func(i, j int) bool { return displayTypes[i] < displayTypes[j] })
+
+	plans := make([]ScreenshotSetPlan, 0, len(displayTypes))
+
+	for _, displayType := range displayTypes {
+		plan := ScreenshotSetPlan{
+			Locale:         locale,
+			LocalizationID: localizationID,
+			DisplayType:    displayType,
+		}
+
+		set, exists := remote[displayType]
+		if exists {
+			plan.SetID = set.ID
+		}
+
+		screenshots, ok := local[displayType]
+		if !ok {
+			plan.Delete = true
+			plans = append(plans, plan)
+
+			continue
+		}
+
+		var existing []AppScreenshot
+
+		if exists {
+			res, _, err := NewPager[AppScreenshot](s.client, func(ctx context.Context) (*AppScreenshotsResponse, *Response, error) {
+				return s.ListAppScreenshotsForSet(ctx, set.ID, &ListAppScreenshotsForSetQuery{Limit: 200})
+			}).All(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			existing = res.Data
+		}
+
+		diffScreenshots(&plan, existing, screenshots)
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// diffScreenshots fills in the changes needed to turn the existing screenshots of a set into
+// the local screenshots, in order.
+func diffScreenshots(plan *ScreenshotSetPlan, existing []AppScreenshot, local []LocalScreenshot) {
+	available := make(map[string][]AppScreenshot)
+
+	for _, screenshot := range existing {
+		checksum := screenshotChecksum(screenshot)
+		available[checksum] = append(available[checksum], screenshot)
+	}
+
+	kept := make(map[string]bool)
+
+	for _, screenshot := range local {
+		if matches := available[screenshot.Checksum]; len(matches) > 0 {
+			available[screenshot.Checksum] = matches[1:]
+			kept[matches[0].ID] = true
+			plan.order = append(plan.order, matches[0].ID)
+
+			continue
+		}
+
+		plan.Add = append(plan.Add, screenshot)
+		plan.order = append(plan.order, screenshot.Checksum)
+	}
+
+	// Uploaded screenshots are appended to the end of the set, so a reorder is needed unless
+	// the kept screenshots are already in order and followed by the new ones.
+	current := make([]string, 0, len(plan.order))
+
+	for _, screenshot := range existing {
+		if kept[screenshot.ID] {
+			current = append(current, screenshot.ID)
+		} else {
+			plan.Remove = append(plan.Remove, screenshot)
+		}
+	}
+
+	for _, screenshot := range plan.Add {
+		current = append(current, screenshot.Checksum)
+	}
+
+	for i := range current {
+		if current[i] != plan.order[i] {
+			plan.Reorder = true
+
+			break
+		}
+	}
+}
+
+// ApplyScreenshotSync applies a plan returned by PlanScreenshotSync. Changes are applied set by
+// set, and the first error stops the sync.
+func (s *AppsService) ApplyScreenshotSync(ctx context.Context, plan *ScreenshotSyncPlan) error {
+	for _, set := range plan.Sets {
+		if err := s.applyScreenshotSetPlan(ctx, set); err != nil {
+			return fmt.Errorf("%s %s: %w", set.Locale, set.DisplayType, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *AppsService) applyScreenshotSetPlan(ctx context.Context, plan ScreenshotSetPlan) error {
+	if plan.Delete {
+		_, err := s.DeleteAppScreenshotSet(ctx, plan.SetID)
+
+		return err
+	}
+
+	setID := plan.SetID
+
+	if setID == "" {
+		res, _, err := s.CreateAppScreenshotSet(ctx, plan.DisplayType, plan.LocalizationID)
+		if err != nil {
+			return err
+		}
+
+		setID = res.Data.ID
+	}
+
+	for _, screenshot := range plan.Remove {
+		if _, err := s.DeleteAppScreenshot(ctx, screenshot.ID); err != nil {
+			return err
+		}
+	}
+
+	uploaded := make(map[string][]string)
+
+	for _, screenshot := range plan.Add {
+		id, err := s.uploadLocalScreenshot(ctx, setID, screenshot)
+		if err != nil {
+			return err
+		}
+
+		uploaded[screenshot.Checksum] = append(uploaded[screenshot.Checksum], id)
+	}
+
+	if !plan.Reorder {
+		return nil
+	}
+
+	ids := make([]string, len(plan.order))
+
+	for i, entry := range plan.order {
+		if pending := uploaded[entry]; len(pending) > 0 {
+			entry, uploaded[entry] = pending[0], pending[1:]
+		}
+
+		ids[i] = entry
+	}
+
+	_, err := s.ReplaceAppScreenshotsForSet(ctx, setID, ids)
+
+	return err
+}
+
+func (s *AppsService) uploadLocalScreenshot(ctx context.Context, setID string, screenshot LocalScreenshot) (string, error) {
+	file, err := os.Open(screenshot.Path)
+	if err != nil {
+		return "", err
+	}
+	defer closeDesc(file)
+
+	res, err := s.UploadScreenshot(ctx, setID, screenshot.FileName, file, screenshot.FileSize)
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
+
+// readScreenshotTree reads the screenshots in a directory tree, grouped by locale and display
+// type and sorted by file name.
+func readScreenshotTree(dir string) (map[string]map[ScreenshotDisplayType][]LocalScreenshot, error) {
+	locales, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]map[ScreenshotDisplayType][]LocalScreenshot)
+
+	for _, locale := range locales {
+		if !locale.IsDir() || strings.HasPrefix(locale.Name(), ".") {
+			continue
+		}
+
+		sets := make(map[ScreenshotDisplayType][]LocalScreenshot)
+		if err := readScreenshotDir(filepath.Join(dir, locale.Name()), "", sets); err != nil {
+			return nil, err
+		}
+
+		tree[locale.Name()] = sets
+	}
+
+	return tree, nil
+}
+
+// readScreenshotDir reads the screenshots in a locale directory, or in a display type directory
+// within it if displayType is not empty.
+func readScreenshotDir(dir string, displayType ScreenshotDisplayType, sets map[ScreenshotDisplayType][]LocalScreenshot) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+
+		if entry.IsDir() {
+			if subdirType := ScreenshotDisplayType(name); displayType == "" && screenshotDisplayTypes[subdirType] {
+				if err := readScreenshotDir(path, subdirType, sets); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if !isScreenshotFile(name) {
+			continue
+		}
+
+		screenshot, config, err := readLocalScreenshot(path)
+		if err != nil {
+			return err
+		}
+
+		fileType := displayType
+		if fileType == "" {
+			inferred, ok := InferScreenshotDisplayType(config.Width, config.Height)
+			if !ok {
+				return ErrUnknownScreenshotSize{Path: path, Width: config.Width, Height: config.Height}
+			}
+
+			fileType = inferred
+		}
+
+		sets[fileType] = append(sets[fileType], screenshot)
+	}
+
+	return nil
+}
+
+func isScreenshotFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	default:
+		return false
+	}
+}
+
+// readLocalScreenshot reads the size, checksum and dimensions of a screenshot file.
+func readLocalScreenshot(path string) (LocalScreenshot, image.Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return LocalScreenshot{}, image.Config{}, err
+	}
+	defer closeDesc(file)
+
+	stat, err := file.Stat()
+	if err != nil {
+		return LocalScreenshot{}, image.Config{}, err
+	}
+
+	checksum, err := assetChecksum(file, stat.Size())
+	if err != nil {
+		return LocalScreenshot{}, image.Config{}, err
+	}
+
+	screenshot := LocalScreenshot{
+		Path:     path,
+		FileName: filepath.Base(path),
+		FileSize: stat.Size(),
+		Checksum: checksum,
+	}
+
+	config, _, err := image.DecodeConfig(io.NewSectionReader(file, 0, stat.Size()))
+	if err != nil {
+		return LocalScreenshot{}, image.Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return screenshot, config, nil
+}
+
+func screenshotChecksum(screenshot AppScreenshot) string {
+	if screenshot.Attributes == nil || screenshot.Attributes.SourceFileChecksum == nil {
+		return ""
+	}
+
+	return *screenshot.Attributes.SourceFileChecksum
+}
+
+func screenshotFileName(screenshot AppScreenshot) string {
+	if screenshot.Attributes == nil || screenshot.Attributes.FileName == nil {
+		return ""
+	}
+
+	return *screenshot.Attributes.FileName
+}
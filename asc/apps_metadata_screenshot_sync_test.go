@@ -0,0 +1,246 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeScreenshot(t *testing.T, path string, width, height int) string {
+	t.Helper()
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+
+	defer file.Close()
+
+	assert.NoError(t, png.Encode(file, image.NewGray(image.Rect(0, 0, width, height))))
+
+	_, err = file.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	stat, err := file.Stat()
+	assert.NoError(t, err)
+
+	checksum, err := assetChecksum(file, stat.Size())
+	assert.NoError(t, err)
+
+	return checksum
+}
+
+// newMockScreenshotSyncServer serves one localization, "en-US", with an APP_IPHONE_65 set holding
+// two screenshots and an APP_IPAD_97 set holding none. It returns the IDs of the screenshots that
+// the APP_IPHONE_65 set is reordered to.
+func newMockScreenshotSyncServer(t *testing.T, keptChecksum string) (*Client, *routeServer, *[]string) {
+	t.Helper()
+
+	var (
+		created  int
+		replaced []string
+	)
+
+	screenshot := func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/appScreenshots/")
+		fmt.Fprintf(w, `{"data":{"id":%q,"type":"appScreenshots","attributes":{"assetDeliveryState":{"state":"COMPLETE"}}}}`, id)
+	}
+
+	client, server := newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /appStoreVersions/10/appStoreVersionLocalizations": respond(`{"data":[{"id":"loc1","type":"appStoreVersionLocalizations","attributes":{"locale":"en-US"}}]}`),
+		"GET /appStoreVersionLocalizations/loc1/appScreenshotSets": respond(`{"data":[
+			{"id":"set1","type":"appScreenshotSets","attributes":{"screenshotDisplayType":"APP_IPHONE_65"}},
+			{"id":"set2","type":"appScreenshotSets","attributes":{"screenshotDisplayType":"APP_IPAD_97"}}
+		]}`),
+		"GET /appScreenshotSets/set1/appScreenshots": respond(fmt.Sprintf(`{"data":[
+			{"id":"old","type":"appScreenshots","attributes":{"fileName":"old.png","sourceFileChecksum":"stale"}},
+			{"id":"kept","type":"appScreenshots","attributes":{"fileName":"b.png","sourceFileChecksum":%q}}
+		]}`, keptChecksum)),
+		"POST /appScreenshotSets": respond(`{"data":{"id":"set3","type":"appScreenshotSets"}}`),
+		"POST /appScreenshots": func(w http.ResponseWriter, r *http.Request) {
+			created++
+			fmt.Fprintf(w, `{"data":{"id":"new%d","type":"appScreenshots"}}`, created)
+		},
+		"PATCH /appScreenshots/new1": respond(`{"data":{"type":"appScreenshots"}}`),
+		"PATCH /appScreenshots/new2": respond(`{"data":{"type":"appScreenshots"}}`),
+		"GET /appScreenshots/new1":   screenshot,
+		"GET /appScreenshots/new2":   screenshot,
+		"PATCH /appScreenshotSets/set1/relationships/appScreenshots": func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Data []RelationshipData `json:"data"`
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &req)
+
+			for _, data := range req.Data {
+				replaced = append(replaced, data.ID)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		},
+		"DELETE /appScreenshotSets/set2": noContent,
+		"DELETE /appScreenshots/old":     noContent,
+	})
+
+	client.SetAssetUploadOptions(&AssetUploadOptions{PollInterval: time.Millisecond})
+
+	return client, server, &replaced
+}
+
+func TestInferScreenshotDisplayType(t *testing.T) {
+	t.Parallel()
+
+	displayType, ok := InferScreenshotDisplayType(1242, 2688)
+	assert.True(t, ok)
+	assert.Equal(t, ScreenshotDisplayTypeAppiPhone65, displayType)
+
+	displayType, ok = InferScreenshotDisplayType(2732, 2048)
+	assert.True(t, ok)
+	assert.Equal(t, ScreenshotDisplayTypeAppiPadPro3Gen129, displayType)
+
+	_, ok = InferScreenshotDisplayType(100, 100)
+	assert.False(t, ok)
+}
+
+func TestReadScreenshotTree(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeScreenshot(t, filepath.Join(dir, "en-US", "2.png"), 640, 1136)
+	writeScreenshot(t, filepath.Join(dir, "en-US", "1.png"), 1136, 640)
+	writeScreenshot(t, filepath.Join(dir, "en-US", "IMESSAGE_APP_IPHONE_40", "1.png"), 10, 10)
+	writeScreenshot(t, filepath.Join(dir, "en-US", "trash", "1.png"), 10, 10)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "en-US", "notes.txt"), []byte("notes"), 0o600))
+
+	tree, err := readScreenshotTree(dir)
+	assert.NoError(t, err)
+	assert.Len(t, tree["en-US"], 2)
+
+	inferred := tree["en-US"][ScreenshotDisplayTypeAppiPhone40]
+	assert.Len(t, inferred, 2)
+	assert.Equal(t, "1.png", inferred[0].FileName)
+	assert.Equal(t, "2.png", inferred[1].FileName)
+	assert.Len(t, tree["en-US"][ScreenshotDisplayTypeiMessageAppIPhone40], 1)
+}
+
+func TestReadScreenshotTreeUnknownSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeScreenshot(t, filepath.Join(dir, "en-US", "1.png"), 10, 10)
+
+	_, err := readScreenshotTree(dir)
+
+	var sizeErr ErrUnknownScreenshotSize
+
+	assert.True(t, errors.As(err, &sizeErr))
+	assert.Equal(t, 10, sizeErr.Width)
+}
+
+func TestDiffScreenshots(t *testing.T) {
+	t.Parallel()
+
+	existing := []AppScreenshot{
+		{ID: "1", Attributes: &AppScreenshotAttributes{SourceFileChecksum: String("a")}},
+		{ID: "2", Attributes: &AppScreenshotAttributes{SourceFileChecksum: String("b")}},
+	}
+
+	plan := ScreenshotSetPlan{SetID: "10"}
+	diffScreenshots(&plan, existing, []LocalScreenshot{{Checksum: "a"}, {Checksum: "b"}})
+	assert.False(t, plan.HasChanges())
+
+	plan = ScreenshotSetPlan{}
+	diffScreenshots(&plan, existing, []LocalScreenshot{{Checksum: "a"}, {Checksum: "b"}, {Checksum: "c"}})
+	assert.Len(t, plan.Add, 1)
+	assert.False(t, plan.Reorder)
+
+	plan = ScreenshotSetPlan{}
+	diffScreenshots(&plan, existing, []LocalScreenshot{{Checksum: "b"}, {Checksum: "a"}})
+	assert.Empty(t, plan.Add)
+	assert.True(t, plan.Reorder)
+	assert.Equal(t, []string{"2", "1"}, plan.order)
+}
+
+func TestSyncScreenshots(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	added := writeScreenshot(t, filepath.Join(dir, "en-US", "a.png"), 1242, 2688)
+	kept := writeScreenshot(t, filepath.Join(dir, "en-US", "b.png"), 1284, 2778)
+	writeScreenshot(t, filepath.Join(dir, "en-US", "IMESSAGE_APP_IPHONE_65", "c.png"), 10, 10)
+
+	client, server, replaced := newMockScreenshotSyncServer(t, kept)
+
+	plan, err := client.Apps.SyncScreenshots(context.Background(), "10", dir, true)
+	assert.NoError(t, err)
+	assert.True(t, plan.HasChanges())
+	assert.Empty(t, server.changes())
+	assert.Equal(t, `en-US APP_IPAD_97: delete set set2
+en-US APP_IPHONE_65: - old.png (old)
+en-US APP_IPHONE_65: + a.png
+en-US APP_IPHONE_65: reorder
+en-US IMESSAGE_APP_IPHONE_65: create set
+en-US IMESSAGE_APP_IPHONE_65: + c.png
+`, plan.String())
+	assert.Equal(t, []string{added, "kept"}, plan.Sets[1].order)
+
+	_, err = client.Apps.SyncScreenshots(context.Background(), "10", dir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE /appScreenshotSets/set2",
+		"DELETE /appScreenshots/old",
+		"POST /appScreenshots",
+		"PATCH /appScreenshots/new1",
+		"PATCH /appScreenshotSets/set1/relationships/appScreenshots",
+		"POST /appScreenshotSets",
+		"POST /appScreenshots",
+		"PATCH /appScreenshots/new2",
+	}, server.changes())
+	assert.Equal(t, []string{"new1", "kept"}, *replaced)
+}
+
+func TestPlanScreenshotSyncUnknownLocale(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeScreenshot(t, filepath.Join(dir, "fr-FR", "a.png"), 1242, 2688)
+
+	client, _, _ := newMockScreenshotSyncServer(t, "")
+
+	_, err := client.Apps.PlanScreenshotSync(context.Background(), "10", dir)
+
+	assert.Equal(t, ErrUnknownLocale{Locale: "fr-FR"}, err)
+}
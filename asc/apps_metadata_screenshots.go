@@ -23,6 +23,7 @@ package asc
 import (
 	"context"
 	"fmt"
+	"io"
 )
 
 // AppScreenshot defines model for AppScreenshot.
@@ -190,3 +191,65 @@ func (s *AppsService) DeleteAppScreenshot(ctx context.Context, id string) (*Resp
 
 	return s.client.delete(ctx, url, nil)
 }
+
+// UploadScreenshot uploads a file as a new screenshot in a screenshot set. It reserves the
+// screenshot, uploads each part of the file, commits the reservation with the file's checksum,
+// and waits for App Store Connect to finish processing the screenshot.
+//
+// If App Store Connect fails to process the screenshot, an ErrAssetDelivery describing the
+// problems is returned. On any failure after the reservation was made, the reservation is deleted
+// so the screenshot set is left unchanged.
+//
+// https://developer.apple.com/documentation/appstoreconnectapi/uploading_assets_to_app_store_connect
+func (s *AppsService) UploadScreenshot(ctx context.Context, appScreenshotSetID string, fileName string, file io.ReaderAt, fileSize int64) (*AppScreenshot, error) {
+	return uploadAsset(ctx, s.client, s.screenshotAsset(appScreenshotSetID, fileName, fileSize), file, fileSize)
+}
+
+// screenshotAsset describes how UploadScreenshot reserves, commits, reads and deletes an app
+// screenshot.
+func (s *AppsService) screenshotAsset(appScreenshotSetID string, fileName string, fileSize int64) assetKind[AppScreenshot] {
+	return assetKind[AppScreenshot]{
+		reserve: func(ctx context.Context) (AppScreenshot, error) {
+			res, _, err := s.CreateAppScreenshot(ctx, fileName, fileSize, appScreenshotSetID)
+			if err != nil {
+				return AppScreenshot{}, err
+			}
+
+			return res.Data, nil
+		},
+		commit: func(ctx context.Context, id string, checksum string) error {
+			_, _, err := s.CommitAppScreenshot(ctx, id, Bool(true), &checksum)
+
+			return err
+		},
+		get: func(ctx context.Context, id string) (AppScreenshot, error) {
+			res, _, err := s.GetAppScreenshot(ctx, id, nil)
+			if err != nil {
+				return AppScreenshot{}, err
+			}
+
+			return res.Data, nil
+		},
+		remove: s.DeleteAppScreenshot,
+	}
+}
+
+func (a AppScreenshot) assetID() string {
+	return a.ID
+}
+
+func (a AppScreenshot) uploadOperations() []UploadOperation {
+	if a.Attributes == nil {
+		return nil
+	}
+
+	return a.Attributes.UploadOperations
+}
+
+func (a AppScreenshot) deliveryState() *AppMediaAssetState {
+	if a.Attributes == nil {
+		return nil
+	}
+
+	return a.Attributes.AssetDeliveryState
+}
@@ -22,7 +22,12 @@ package asc
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetAppScreenshot(t *testing.T) {
@@ -56,3 +61,62 @@ func TestDeleteAppScreenshot(t *testing.T) {
 		return client.Apps.DeleteAppScreenshot(ctx, "10")
 	})
 }
+
+func TestUploadScreenshot(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "appScreenshots", AppMediaAssetStateComplete)
+
+	screenshot, err := client.Apps.UploadScreenshot(context.Background(), "10", "screenshot.png", strings.NewReader("contents"), 8)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", screenshot.ID)
+	assert.Equal(t, "contents", string(server.uploaded))
+	assert.Equal(t, true, server.commitAttributes()["uploaded"])
+	assert.Equal(t, "98bf7d8c15784f0a3d63204441e1e2aa", server.commitAttributes()["sourceFileChecksum"])
+	assert.Equal(t, 2, server.polls)
+	assert.False(t, server.deleted)
+}
+
+func TestUploadScreenshotDeliveryFailed(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "appScreenshots", AppMediaAssetStateFailed)
+
+	screenshot, err := client.Apps.UploadScreenshot(context.Background(), "10", "screenshot.png", strings.NewReader("contents"), 8)
+
+	var deliveryErr ErrAssetDelivery
+
+	assert.Nil(t, screenshot)
+	assert.True(t, errors.As(err, &deliveryErr))
+	assert.Equal(t, "1", deliveryErr.ID)
+	assert.Equal(t, "IMAGE_INCORRECT_DIMENSIONS", *deliveryErr.Errors[0].Code)
+	assert.True(t, server.deleted)
+}
+
+func TestUploadScreenshotUploadFailed(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "appScreenshots", AppMediaAssetStateComplete)
+
+	server.uploadCode = http.StatusBadRequest
+
+	_, err := client.Apps.UploadScreenshot(context.Background(), "10", "screenshot.png", strings.NewReader("contents"), 8)
+
+	var uploadErr *UploadError
+
+	assert.True(t, errors.As(err, &uploadErr))
+	assert.True(t, server.deleted)
+}
+
+func TestUploadScreenshotReservationFailed(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "appScreenshots", AppMediaAssetStateComplete)
+	server.handle("POST /appScreenshots", notFound)
+
+	_, err := client.Apps.UploadScreenshot(context.Background(), "10", "screenshot.png", strings.NewReader("contents"), 8)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.False(t, server.deleted)
+}
@@ -0,0 +1,449 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Metadata is the desired state of the localized metadata of an app, keyed by locale. It can
+// be loaded from JSON or YAML with LoadMetadata.
+type Metadata struct {
+	Locales map[string]LocaleMetadata `json:"locales" yaml:"locales"`
+}
+
+// LocaleMetadata is the desired metadata for a single locale. Fields that are nil are left
+// unchanged, while fields set to an empty string are cleared.
+//
+// Name, Subtitle, PrivacyPolicyURL and PrivacyPolicyText belong to the app info localization,
+// and the remaining fields belong to the App Store version localization.
+type LocaleMetadata struct {
+	Name              *string `json:"name,omitempty" yaml:"name,omitempty"`
+	Subtitle          *string `json:"subtitle,omitempty" yaml:"subtitle,omitempty"`
+	PrivacyPolicyURL  *string `json:"privacyPolicyUrl,omitempty" yaml:"privacyPolicyUrl,omitempty"`
+	PrivacyPolicyText *string `json:"privacyPolicyText,omitempty" yaml:"privacyPolicyText,omitempty"`
+	Description       *string `json:"description,omitempty" yaml:"description,omitempty"`
+	Keywords          *string `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+	MarketingURL      *string `json:"marketingUrl,omitempty" yaml:"marketingUrl,omitempty"`
+	PromotionalText   *string `json:"promotionalText,omitempty" yaml:"promotionalText,omitempty"`
+	SupportURL        *string `json:"supportUrl,omitempty" yaml:"supportUrl,omitempty"`
+	WhatsNew          *string `json:"whatsNew,omitempty" yaml:"whatsNew,omitempty"`
+}
+
+// LoadMetadata loads a Metadata document from path. If path is a file, it holds a whole
+// Metadata document. If path is a directory, each file in it named after a locale, such as
+// "en-US.yml", holds the LocaleMetadata for that locale. Files ending in ".json" are decoded
+// as JSON, and all other files as YAML.
+func LoadMetadata(path string) (*Metadata, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &Metadata{Locales: make(map[string]LocaleMetadata)}
+
+	if !stat.IsDir() {
+		return metadata, decodeMetadataFile(path, metadata)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		switch strings.ToLower(ext) {
+		case ".json", ".yml", ".yaml":
+		default:
+			continue
+		}
+
+		var locale LocaleMetadata
+		if err := decodeMetadataFile(filepath.Join(path, name), &locale); err != nil {
+			return nil, err
+		}
+
+		metadata.Locales[strings.TrimSuffix(name, ext)] = locale
+	}
+
+	return metadata, nil
+}
+
+func decodeMetadataFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, v)
+	} else {
+		err = yaml.Unmarshal(data, v)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// MetadataSyncOptions selects the resources a metadata sync applies to.
+type MetadataSyncOptions struct {
+	// AppStoreVersionID is the App Store version whose localizations are synced. If empty,
+	// version localizations are left alone.
+	AppStoreVersionID string
+	// AppInfoID is the app info whose localizations are synced. If empty, app info
+	// localizations are left alone.
+	AppInfoID string
+	// Prune deletes the localizations of locales that are not in the Metadata document.
+	Prune bool
+}
+
+// MetadataChangeKind is the kind of change a MetadataChange makes.
+type MetadataChangeKind string
+
+const (
+	// MetadataChangeCreate creates a localization.
+	MetadataChangeCreate MetadataChangeKind = "create"
+	// MetadataChangeUpdate updates some fields of an existing localization.
+	MetadataChangeUpdate MetadataChangeKind = "update"
+	// MetadataChangeDelete deletes an existing localization.
+	MetadataChangeDelete MetadataChangeKind = "delete"
+)
+
+// MetadataFieldChange is a change to a single field of a localization.
+type MetadataFieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// MetadataChange is a change to a single localization.
+type MetadataChange struct {
+	Kind MetadataChangeKind
+	// Type is the resource type of the localization, either "appStoreVersionLocalizations"
+	// or "appInfoLocalizations".
+	Type   string
+	Locale string
+	// ID is the ID of the existing localization, or empty if it will be created.
+	ID     string
+	Fields []MetadataFieldChange
+
+	desired LocaleMetadata
+}
+
+// MetadataSyncPlan is the minimal set of changes needed to make the live localizations of an
+// app match a Metadata document. It is returned by AppsService.PlanMetadataSync and applied
+// with AppsService.ApplyMetadataSync.
+type MetadataSyncPlan struct {
+	Changes []MetadataChange
+}
+
+// String formats the plan for review, with one line per change followed by one indented line
+// per changed field.
+func (p *MetadataSyncPlan) String() string {
+	var b strings.Builder
+
+	for _, change := range p.Changes {
+		fmt.Fprintf(&b, "%s %s %s", change.Kind, change.Type, change.Locale)
+
+		if change.ID != "" {
+			fmt.Fprintf(&b, " (%s)", change.ID)
+		}
+
+		b.WriteString("\n")
+
+		for _, field := range change.Fields {
+			fmt.Fprintf(&b, "  %s: %q -> %q\n", field.Field, field.Old, field.New)
+		}
+	}
+
+	return b.String()
+}
+
+// metadataField maps one LocaleMetadata field to the attributes of the localization type L it
+// belongs to.
+type metadataField[L any] struct {
+	name    string
+	desired func(m LocaleMetadata) *string
+	live    func(l L) *string
+}
+
+var versionLocalizationFields = []metadataField[AppStoreVersionLocalizationAttributes]{
+	{"description", func(m LocaleMetadata) *string { return m.Description }, func(l AppStoreVersionLocalizationAttributes) *string { return l.Description }},
+	{"keywords", func(m LocaleMetadata) *string { return m.Keywords }, func(l AppStoreVersionLocalizationAttributes) *string { return l.Keywords }},
+	{"marketingUrl", func(m LocaleMetadata) *string { return m.MarketingURL }, func(l AppStoreVersionLocalizationAttributes) *string { return l.MarketingURL }},
+	{"promotionalText", func(m LocaleMetadata) *string { return m.PromotionalText }, func(l AppStoreVersionLocalizationAttributes) *string { return l.PromotionalText }},
+	{"supportUrl", func(m LocaleMetadata) *string { return m.SupportURL }, func(l AppStoreVersionLocalizationAttributes) *string { return l.SupportURL }},
+	{"whatsNew", func(m LocaleMetadata) *string { return m.WhatsNew }, func(l AppStoreVersionLocalizationAttributes) *string { return l.WhatsNew }},
+}
+
+var infoLocalizationFields = []metadataField[AppInfoLocalizationAttributes]{
+	{"name", func(m LocaleMetadata) *string { return m.Name }, func(l AppInfoLocalizationAttributes) *string { return l.Name }},
+	{"privacyPolicyText", func(m LocaleMetadata) *string { return m.PrivacyPolicyText }, func(l AppInfoLocalizationAttributes) *string { return l.PrivacyPolicyText }},
+	{"privacyPolicyUrl", func(m LocaleMetadata) *string { return m.PrivacyPolicyURL }, func(l AppInfoLocalizationAttributes) *string { return l.PrivacyPolicyURL }},
+	{"subtitle", func(m LocaleMetadata) *string { return m.Subtitle }, func(l AppInfoLocalizationAttributes) *string { return l.Subtitle }},
+}
+
+// SyncMetadata makes the live localizations of an app match a Metadata document, and returns
+// the plan that was applied. If dryRun is true, the plan is returned without being applied.
+func (s *AppsService) SyncMetadata(ctx context.Context, metadata *Metadata, opts MetadataSyncOptions, dryRun bool) (*MetadataSyncPlan, error) {
+	plan, err := s.PlanMetadataSync(ctx, metadata, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return plan, nil
+	}
+
+	return plan, s.ApplyMetadataSync(ctx, plan, opts)
+}
+
+// PlanMetadataSync compares a Metadata document with the live App Store version and app info
+// localizations selected by opts, without changing anything. A localization is created for
+// each locale in the document that has no localization yet and sets at least one field of that
+// localization type, and only fields whose value differs are updated.
+//
+// The document is checked with Metadata.Validate first, so a *ValidationError is returned
+// before any request is made if it exceeds the limits of App Store Connect.
+func (s *AppsService) PlanMetadataSync(ctx context.Context, metadata *Metadata, opts MetadataSyncOptions) (*MetadataSyncPlan, error) {
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan := new(MetadataSyncPlan)
+
+	if opts.AppStoreVersionID != "" {
+		res, _, err := NewPager[AppStoreVersionLocalization](s.client, func(ctx context.Context) (*AppStoreVersionLocalizationsResponse, *Response, error) {
+			return s.ListLocalizationsForAppStoreVersion(ctx, opts.AppStoreVersionID, &ListLocalizationsForAppStoreVersionQuery{Limit: 200})
+		}).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		live := make(map[string]localizationState[AppStoreVersionLocalizationAttributes])
+
+		for _, localization := range res.Data {
+			if localization.Attributes != nil && localization.Attributes.Locale != nil {
+				live[*localization.Attributes.Locale] = localizationState[AppStoreVersionLocalizationAttributes]{localization.ID, *localization.Attributes}
+			}
+		}
+
+		plan.Changes = append(plan.Changes, diffLocalizations("appStoreVersionLocalizations", metadata, live, versionLocalizationFields, opts.Prune)...)
+	}
+
+	if opts.AppInfoID != "" {
+		res, _, err := NewPager[AppInfoLocalization](s.client, func(ctx context.Context) (*AppInfoLocalizationsResponse, *Response, error) {
+			return s.ListAppInfoLocalizationsForAppInfo(ctx, opts.AppInfoID, &ListAppInfoLocalizationsForAppInfoQuery{Limit: 200})
+		}).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		live := make(map[string]localizationState[AppInfoLocalizationAttributes])
+
+		for _, localization := range res.Data {
+			if localization.Attributes != nil && localization.Attributes.Locale != nil {
+				live[*localization.Attributes.Locale] = localizationState[AppInfoLocalizationAttributes]{localization.ID, *localization.Attributes}
+			}
+		}
+
+		plan.Changes = append(plan.Changes, diffLocalizations("appInfoLocalizations", metadata, live, infoLocalizationFields, opts.Prune)...)
+	}
+
+	return plan, nil
+}
+
+type localizationState[L any] struct {
+	id         string
+	attributes L
+}
+
+// diffLocalizations computes the changes for one localization type, ordered by locale.
+func diffLocalizations[L any](resourceType string, metadata *Metadata, live map[string]localizationState[L], fields []metadataField[L], prune bool) []MetadataChange {
+	locales := make([]string, 0, len(metadata.Locales)+len(live))
+
+	for locale := range metadata.Locales {
+		locales = append(locales, locale)
+	}
+
+	for locale := range live {
+		if _, ok := metadata.Locales[locale]; !ok {
+			locales = append(locales, locale)
+		}
+	}
+
+	sort.Strings(locales)
+
+	var changes []MetadataChange
+
+	for _, locale := range locales {
+		desired, wanted := metadata.Locales[locale]
+		state, exists := live[locale]
+
+		change := MetadataChange{Type: resourceType, Locale: locale, ID: state.id, desired: desired}
+
+		switch {
+		case !wanted:
+			if !prune {
+				continue
+			}
+
+			change.Kind = MetadataChangeDelete
+		case !exists:
+			change.Kind = MetadataChangeCreate
+		default:
+			change.Kind = MetadataChangeUpdate
+		}
+
+		if change.Kind != MetadataChangeDelete {
+			for _, field := range fields {
+				value := field.desired(desired)
+				if value == nil {
+					continue
+				}
+
+				var old string
+				if exists {
+					old = stringValue(field.live(state.attributes))
+				}
+
+				if *value != old {
+					change.Fields = append(change.Fields, MetadataFieldChange{Field: field.name, Old: old, New: *value})
+				}
+			}
+
+			if len(change.Fields) == 0 {
+				continue
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// ApplyMetadataSync applies a plan returned by PlanMetadataSync with the same options. The
+// first error stops the sync.
+func (s *AppsService) ApplyMetadataSync(ctx context.Context, plan *MetadataSyncPlan, opts MetadataSyncOptions) error {
+	for _, change := range plan.Changes {
+		if err := s.applyMetadataChange(ctx, change, opts); err != nil {
+			return fmt.Errorf("%s %s %s: %w", change.Kind, change.Type, change.Locale, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *AppsService) applyMetadataChange(ctx context.Context, change MetadataChange, opts MetadataSyncOptions) error {
+	var err error
+
+	changed := make(map[string]bool, len(change.Fields))
+	for _, field := range change.Fields {
+		changed[field.Field] = true
+	}
+
+	// pick returns the desired value of a field only if the change touches it.
+	pick := func(name string, value *string) *string {
+		if !changed[name] {
+			return nil
+		}
+
+		return value
+	}
+
+	desired := change.desired
+
+	switch change.Type {
+	case "appStoreVersionLocalizations":
+		switch change.Kind {
+		case MetadataChangeCreate:
+			_, _, err = s.CreateAppStoreVersionLocalization(ctx, AppStoreVersionLocalizationCreateRequestAttributes{
+				Locale:          change.Locale,
+				Description:     pick("description", desired.Description),
+				Keywords:        pick("keywords", desired.Keywords),
+				MarketingURL:    pick("marketingUrl", desired.MarketingURL),
+				PromotionalText: pick("promotionalText", desired.PromotionalText),
+				SupportURL:      pick("supportUrl", desired.SupportURL),
+				WhatsNew:        pick("whatsNew", desired.WhatsNew),
+			}, opts.AppStoreVersionID)
+		case MetadataChangeUpdate:
+			_, _, err = s.UpdateAppStoreVersionLocalization(ctx, change.ID, &AppStoreVersionLocalizationUpdateRequestAttributes{
+				Description:     pick("description", desired.Description),
+				Keywords:        pick("keywords", desired.Keywords),
+				MarketingURL:    pick("marketingUrl", desired.MarketingURL),
+				PromotionalText: pick("promotionalText", desired.PromotionalText),
+				SupportURL:      pick("supportUrl", desired.SupportURL),
+				WhatsNew:        pick("whatsNew", desired.WhatsNew),
+			})
+		case MetadataChangeDelete:
+			_, err = s.DeleteAppStoreVersionLocalization(ctx, change.ID)
+		}
+	case "appInfoLocalizations":
+		switch change.Kind {
+		case MetadataChangeCreate:
+			_, _, err = s.CreateAppInfoLocalization(ctx, AppInfoLocalizationCreateRequestAttributes{
+				Locale:            change.Locale,
+				Name:              pick("name", desired.Name),
+				PrivacyPolicyText: pick("privacyPolicyText", desired.PrivacyPolicyText),
+				PrivacyPolicyURL:  pick("privacyPolicyUrl", desired.PrivacyPolicyURL),
+				Subtitle:          pick("subtitle", desired.Subtitle),
+			}, opts.AppInfoID)
+		case MetadataChangeUpdate:
+			_, _, err = s.UpdateAppInfoLocalization(ctx, change.ID, &AppInfoLocalizationUpdateRequestAttributes{
+				Name:              pick("name", desired.Name),
+				PrivacyPolicyText: pick("privacyPolicyText", desired.PrivacyPolicyText),
+				PrivacyPolicyURL:  pick("privacyPolicyUrl", desired.PrivacyPolicyURL),
+				Subtitle:          pick("subtitle", desired.Subtitle),
+			})
+		case MetadataChangeDelete:
+			_, err = s.DeleteAppInfoLocalization(ctx, change.ID)
+		}
+	}
+
+	return err
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,169 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockMetadataServer serves an App Store version with "en-US" and "de-DE" localizations and
+// an app info with an "en-US" localization. It returns the attributes of every change made to
+// them, keyed by route.
+func newMockMetadataServer(t *testing.T) (*Client, *routeServer, map[string]map[string]interface{}) {
+	t.Helper()
+
+	bodies := make(map[string]map[string]interface{})
+
+	change := func(w http.ResponseWriter, r *http.Request) {
+		bodies[r.Method+" "+r.URL.Path] = requestAttributes(decodeRequest(r))
+
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		fmt.Fprint(w, `{"data":{"id":"new"}}`)
+	}
+
+	client, server := newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /appStoreVersions/10/appStoreVersionLocalizations": respond(`{"data":[
+			{"id":"v-en","type":"appStoreVersionLocalizations","attributes":{"locale":"en-US","description":"An app","whatsNew":"Bug fixes"}},
+			{"id":"v-de","type":"appStoreVersionLocalizations","attributes":{"locale":"de-DE","description":"Eine App"}}
+		]}`),
+		"GET /appInfos/20/appInfoLocalizations": respond(`{"data":[
+			{"id":"i-en","type":"appInfoLocalizations","attributes":{"locale":"en-US","name":"App","subtitle":"Old"}}
+		]}`),
+		"DELETE /appStoreVersionLocalizations/v-de": change,
+		"PATCH /appStoreVersionLocalizations/v-en":  change,
+		"POST /appStoreVersionLocalizations":        change,
+		"PATCH /appInfoLocalizations/i-en":          change,
+		"POST /appInfoLocalizations":                change,
+	})
+
+	return client, server, bodies
+}
+
+func TestLoadMetadata(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "en-US.yml"), []byte("name: App\nwhatsNew: |\n  Bug fixes\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "fr-FR.json"), []byte(`{"name":"Appli","keywords":""}`), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Metadata"), 0o600))
+
+	metadata, err := LoadMetadata(dir)
+	assert.NoError(t, err)
+	assert.Len(t, metadata.Locales, 2)
+	assert.Equal(t, "Bug fixes\n", *metadata.Locales["en-US"].WhatsNew)
+	assert.Nil(t, metadata.Locales["en-US"].Keywords)
+	assert.Equal(t, "", *metadata.Locales["fr-FR"].Keywords)
+
+	file := filepath.Join(dir, "metadata.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("locales:\n  en-US:\n    subtitle: Sub\n"), 0o600))
+
+	metadata, err = LoadMetadata(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "Sub", *metadata.Locales["en-US"].Subtitle)
+
+	assert.NoError(t, os.WriteFile(file, []byte("locales: ["), 0o600))
+
+	_, err = LoadMetadata(file)
+	assert.Error(t, err)
+}
+
+func TestSyncMetadata(t *testing.T) {
+	t.Parallel()
+
+	client, server, bodies := newMockMetadataServer(t)
+
+	metadata := &Metadata{Locales: map[string]LocaleMetadata{
+		"en-US": {Name: String("App"), Subtitle: String("New"), Description: String("An app"), WhatsNew: String("")},
+		"fr-FR": {Name: String("Appli"), Description: String("Une appli")},
+	}}
+	opts := MetadataSyncOptions{AppStoreVersionID: "10", AppInfoID: "20", Prune: true}
+
+	plan, err := client.Apps.SyncMetadata(context.Background(), metadata, opts, true)
+	assert.NoError(t, err)
+	assert.Empty(t, server.changes())
+	assert.Equal(t, `delete appStoreVersionLocalizations de-DE (v-de)
+update appStoreVersionLocalizations en-US (v-en)
+  whatsNew: "Bug fixes" -> ""
+create appStoreVersionLocalizations fr-FR
+  description: "" -> "Une appli"
+update appInfoLocalizations en-US (i-en)
+  subtitle: "Old" -> "New"
+create appInfoLocalizations fr-FR
+  name: "" -> "Appli"
+`, plan.String())
+
+	_, err = client.Apps.SyncMetadata(context.Background(), metadata, opts, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE /appStoreVersionLocalizations/v-de",
+		"PATCH /appStoreVersionLocalizations/v-en",
+		"POST /appStoreVersionLocalizations",
+		"PATCH /appInfoLocalizations/i-en",
+		"POST /appInfoLocalizations",
+	}, server.changes())
+	assert.Equal(t, map[string]interface{}{"whatsNew": ""}, bodies["PATCH /appStoreVersionLocalizations/v-en"])
+	assert.Equal(t, map[string]interface{}{"subtitle": "New"}, bodies["PATCH /appInfoLocalizations/i-en"])
+	assert.Equal(t, map[string]interface{}{"locale": "fr-FR", "name": "Appli"}, bodies["POST /appInfoLocalizations"])
+}
+
+func TestPlanMetadataSyncWithoutPrune(t *testing.T) {
+	t.Parallel()
+
+	client, _, _ := newMockMetadataServer(t)
+
+	metadata := &Metadata{Locales: map[string]LocaleMetadata{
+		"en-US": {Description: String("An app"), Name: String("App")},
+	}}
+
+	plan, err := client.Apps.PlanMetadataSync(context.Background(), metadata, MetadataSyncOptions{AppStoreVersionID: "10", AppInfoID: "20"})
+	assert.NoError(t, err)
+	assert.Empty(t, plan.Changes)
+	assert.Equal(t, "", plan.String())
+}
+
+func TestPlanMetadataSyncInvalid(t *testing.T) {
+	t.Parallel()
+
+	client, _, _ := newMockMetadataServer(t)
+
+	metadata := &Metadata{Locales: map[string]LocaleMetadata{
+		"en-US": {Name: String("An app name that is far too long")},
+	}}
+
+	_, err := client.Apps.PlanMetadataSync(context.Background(), metadata, MetadataSyncOptions{AppInfoID: "20"})
+
+	var validationErr *ValidationError
+
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "/locales/en-US/name", validationErr.Violations[0].Pointer)
+}
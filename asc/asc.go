@@ -24,11 +24,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -54,6 +54,13 @@ type Client struct {
 	UserAgent string
 	httpDebug bool
 
+	governor    *RateGovernor
+	retryPolicy *RetryPolicy
+	observer    Observer
+
+	uploadOptions *UploadOptions
+	assetOptions  *AssetUploadOptions
+
 	common service
 
 	Apps         *AppsService
@@ -80,9 +87,10 @@ func NewClient(httpClient *http.Client) *Client {
 	baseURL, _ := url.Parse(defaultBaseURL)
 
 	c := &Client{
-		client:    httpClient,
-		baseURL:   baseURL,
-		UserAgent: userAgent,
+		client:      httpClient,
+		baseURL:     baseURL,
+		UserAgent:   userAgent,
+		retryPolicy: DefaultRetryPolicy(),
 	}
 
 	c.common.client = c
@@ -101,6 +109,8 @@ func NewClient(httpClient *http.Client) *Client {
 }
 
 // SetHTTPDebug this enables global http request/response dumping for this API.
+// Authorization headers and the bodies of asset uploads are redacted from the dumps.
+// For structured output, use SetObserver instead.
 func (c *Client) SetHTTPDebug(flag bool) {
 	c.httpDebug = flag
 }
@@ -336,44 +346,107 @@ func (c *Client) newRequest(ctx context.Context, method string, path string, bod
 }
 
 func (c *Client) do(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
-	respCh := make(chan *http.Response, 1)
+	return c.doWithRetry(ctx, req, v, c.retryPolicy)
+}
+
+// doWithRetry sends the request, retrying transient failures according to the given policy.
+func (c *Client) doWithRetry(ctx context.Context, req *http.Request, v interface{}, policy *RetryPolicy) (*Response, error) {
+	var (
+		resp    *http.Response
+		attempt int
+	)
+
+	bo := policy.backOff(ctx)
+
 	op := func() error {
-		if c.httpDebug {
-			if dump, err := httputil.DumpRequest(req, true); err == nil {
-				fmt.Printf("DEBUG request uri=%s\n%s\n", req.URL, dump) // nolint: forbidigo
+		attempt++
+
+		if attempt > 1 {
+			if err := rewindBody(req); err != nil {
+				return backoff.Permanent(err)
 			}
 		}
 
-		resp, err := c.client.Do(req) // nolint: bodyclose
-		if err != nil {
-			select {
-			case <-ctx.Done():
-				return backoff.Permanent(ctx.Err())
-			default:
+		if c.governor != nil {
+			if err := c.governor.Wait(ctx); err != nil {
 				return backoff.Permanent(err)
 			}
 		}
 
-		if c.httpDebug {
-			if dump, err := httputil.DumpResponse(resp, true); err == nil {
-				fmt.Printf("DEBUG response uri=%s\n%s\n", req.URL, dump) // nolint: forbidigo
+		c.observeRequest(req, attempt)
+
+		sent := time.Now()
+
+		r, err := c.client.Do(req) // nolint: bodyclose
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return backoff.Permanent(ctxErr)
 			}
+
+			if !policy.retryable(req, nil, err) {
+				return backoff.Permanent(err)
+			}
+
+			return err
 		}
 
-		respCh <- resp
+		if c.governor != nil {
+			c.governor.Observe(r)
+		}
+
+		c.observeResponse(req, r, attempt, time.Since(sent))
+
+		resp = r
+
+		if policy.retryable(req, r, nil) {
+			bo.retryAfter = retryAfterDelay(r)
+
+			return retryableStatusError{StatusCode: r.StatusCode}
+		}
 
 		return nil
 	}
 
 	notify := func(err error, delay time.Duration) {
-		if c.httpDebug {
-			fmt.Printf("DEBUG error %v, retry in %v\n", err, delay) // nolint: forbidigo
+		event := RetryAttempt{
+			Request: req,
+			Attempt: attempt,
+			Err:     err,
+			Delay:   delay,
+		}
+
+		if resp != nil {
+			event.StatusCode = resp.StatusCode
+			closeDesc(resp.Body)
+			resp = nil
 		}
+
+		c.observeRetry(req, event)
+		policy.notify(event)
 	}
 
-	err := backoff.RetryNotify(op, backoff.NewExponentialBackOff(), notify)
+	start := time.Now()
 
-	resp := <-respCh
+	err := backoff.RetryNotify(op, bo, notify)
+
+	response, err := decodeResponse(resp, err, v)
+	c.observeError(req, response, attempt, time.Since(start), err)
+
+	return response, err
+}
+
+// decodeResponse turns the outcome of the last attempt of a request into a Response, decoding the
+// response body into v.
+func decodeResponse(resp *http.Response, err error, v interface{}) (*Response, error) {
+	if resp == nil {
+		return nil, err
+	}
+
+	// The last attempt produced a response, so any error it carries is reported by checkResponse.
+	var statusErr retryableStatusError
+	if errors.As(err, &statusErr) {
+		err = nil
+	}
 
 	defer closeDesc(resp.Body)
 
@@ -29,6 +29,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -287,6 +288,110 @@ func newServer(raw string, status int, addRateLimit bool) (*Client, *httptest.Se
 	return client, server
 }
 
+// routeServer is a mock of the App Store Connect API that serves each request with the handler of
+// its route, such as "GET /apps/1", and records the route of every request it receives. Handlers
+// are called one at a time while mu is held, so they can share state without locking.
+type routeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]http.HandlerFunc
+	routes   []string
+}
+
+// newRouteServer starts a routeServer that is closed when the test ends, and returns a client
+// for it with retries disabled. Requests without a route fail the test.
+func newRouteServer(t *testing.T, routes map[string]http.HandlerFunc) (*Client, *routeServer) {
+	t.Helper()
+
+	server := &routeServer{handlers: routes}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		route := r.Method + " " + r.URL.Path
+		server.routes = append(server.routes, route)
+
+		handler, ok := server.handlers[route]
+		if !ok {
+			t.Errorf("unexpected request %s", route)
+			notFound(w, r)
+
+			return
+		}
+
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	base, _ := url.Parse(server.URL + "/")
+	client := NewClient(server.Client())
+	client.baseURL = base
+	client.SetRetryPolicy(nil)
+
+	return client, server
+}
+
+// handle adds or replaces the handler of a route.
+func (s *routeServer) handle(route string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[route] = handler
+}
+
+// requests returns the routes of every request received so far.
+func (s *routeServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.routes...)
+}
+
+// reset forgets the requests received so far.
+func (s *routeServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes = nil
+}
+
+// changes returns the routes of the requests received so far that are not GET requests.
+func (s *routeServer) changes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changes []string
+
+	for _, route := range s.routes {
+		if !strings.HasPrefix(route, http.MethodGet+" ") {
+			changes = append(changes, route)
+		}
+	}
+
+	return changes
+}
+
+// respond returns a handler that writes body with a 200 status.
+func respond(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}
+}
+
+// noContent is a handler that writes an empty 204 response, as App Store Connect does for
+// successful deletions.
+func noContent(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// notFound is a handler that writes the error App Store Connect returns for a resource that does
+// not exist.
+func notFound(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `{"errors":[{"status":"404","code":"NOT_FOUND"}]}`)
+}
+
 func testEndpointWithResponse(t *testing.T, marshalledGot string, want interface{}, endpoint func(ctx context.Context, client *Client) (interface{}, *Response, error)) {
 	t.Helper()
 
@@ -0,0 +1,301 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"crypto/md5" // nolint: gosec
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAssetPollInterval    = 2 * time.Second
+	defaultAssetDeliveryTimeout = 10 * time.Minute
+	assetCleanupTimeout         = 30 * time.Second
+)
+
+// ErrAssetDelivery happens when App Store Connect fails to process an uploaded asset, such as
+// a screenshot that has the wrong dimensions.
+type ErrAssetDelivery struct {
+	// ID is the ID of the asset that failed.
+	ID string
+	// Errors are the errors reported in the asset's delivery state.
+	Errors []AppMediaStateError
+}
+
+func (e ErrAssetDelivery) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("asset %s failed processing: %s", e.ID, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the first error reported in the asset's delivery state, if any.
+func (e ErrAssetDelivery) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e.Errors[0]
+}
+
+// ErrAssetDeliveryTimeout happens when App Store Connect does not finish processing an uploaded
+// asset within AssetUploadOptions.Timeout.
+type ErrAssetDeliveryTimeout struct {
+	// ID is the ID of the asset.
+	ID string
+	// State is the last delivery state that was observed, if any.
+	State   string
+	Timeout time.Duration
+}
+
+func (e ErrAssetDeliveryTimeout) Error() string {
+	return fmt.Sprintf("asset %s was not processed within %s, last state was %s", e.ID, e.Timeout, e.State)
+}
+
+// AssetUploadOptions configures the asset upload workflows, such as AppsService.UploadScreenshot
+// and AppsService.UploadPreview, once the asset's file is uploaded.
+type AssetUploadOptions struct {
+	// PollInterval is how often the delivery state of an uploaded asset is checked. Defaults to
+	// 2 seconds.
+	PollInterval time.Duration
+	// Timeout bounds the wait for App Store Connect to process an uploaded asset. Defaults to
+	// 10 minutes.
+	Timeout time.Duration
+}
+
+// asset is implemented by the resources that are uploaded through the reserve, upload, commit
+// and poll cycle: screenshots, previews, routing app coverage files and review attachments.
+type asset interface {
+	assetID() string
+	uploadOperations() []UploadOperation
+	deliveryState() *AppMediaAssetState
+}
+
+// assetKind plugs one kind of asset into uploadAsset. Each function wraps the endpoint of the
+// same name for that resource.
+type assetKind[T asset] struct {
+	reserve func(ctx context.Context) (T, error)
+	commit  func(ctx context.Context, id string, checksum string) error
+	get     func(ctx context.Context, id string) (T, error)
+	remove  func(ctx context.Context, id string) (*Response, error)
+}
+
+// uploadAsset reserves an asset, uploads each part of the file, commits the reservation with the
+// file's checksum, and waits for App Store Connect to finish processing the asset. On any failure
+// after the reservation was made, the reservation is deleted.
+func uploadAsset[T asset](ctx context.Context, client *Client, kind assetKind[T], file io.ReaderAt, fileSize int64) (*T, error) {
+	checksum, err := assetChecksum(file, fileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := kind.reserve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := reservation.assetID()
+
+	delivered, err := deliverAsset(ctx, client, kind, reservation, file, checksum)
+	if err != nil {
+		cleanupAsset(func(ctx context.Context) (*Response, error) {
+			return kind.remove(ctx, id)
+		})
+
+		return nil, err
+	}
+
+	return delivered, nil
+}
+
+func deliverAsset[T asset](ctx context.Context, client *Client, kind assetKind[T], reservation T, file io.ReaderAt, checksum string) (*T, error) {
+	id := reservation.assetID()
+
+	if err := client.Upload(ctx, reservation.uploadOperations(), file); err != nil {
+		return nil, err
+	}
+
+	if err := kind.commit(ctx, id, checksum); err != nil {
+		return nil, err
+	}
+
+	var delivered T
+
+	err := waitForAssetDelivery(ctx, id, client.assetUploadOptions(), func(ctx context.Context) (*AppMediaAssetState, error) {
+		res, err := kind.get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		delivered = res
+
+		return delivered.deliveryState(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivered, nil
+}
+
+// previewMimeTypes are the video formats App Store Connect accepts for app previews.
+var previewMimeTypes = map[string]string{
+	".m4v": "video/x-m4v",
+	".mov": "video/quicktime",
+	".mp4": "video/mp4",
+}
+
+// DetectMimeType returns the MIME type of an asset file. The file name's extension is consulted
+// first, so that the video formats used for app previews are recognized reliably, and the
+// contents of the file are sniffed otherwise.
+func DetectMimeType(fileName string, file io.ReaderAt) string {
+	if mimeType, ok := previewMimeTypes[strings.ToLower(filepath.Ext(fileName))]; ok {
+		return mimeType
+	}
+
+	header := make([]byte, 512)
+	n, _ := file.ReadAt(header, 0)
+
+	return http.DetectContentType(header[:n])
+}
+
+// PreviewFrameTimeCode formats an offset into an app preview as the HH:MM:SS:FF time code used
+// to choose the preview's poster frame, where FF is the frame number within the second at the
+// given frame rate.
+func PreviewFrameTimeCode(offset time.Duration, frameRate int) string {
+	if offset < 0 {
+		offset = 0
+	}
+
+	seconds := int(offset / time.Second)
+
+	var frame int
+	if frameRate > 0 {
+		frame = int((offset % time.Second) * time.Duration(frameRate) / time.Second)
+	}
+
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60, frame)
+}
+
+// SetUploadOptions sets the default options used by Upload, including when the asset upload
+// workflows upload a file. Passing nil restores the defaults.
+func (c *Client) SetUploadOptions(opts *UploadOptions) {
+	c.uploadOptions = opts
+}
+
+// SetAssetUploadOptions sets the options used by the asset upload workflows, such as
+// AppsService.UploadScreenshot and AppsService.UploadPreview. Passing nil restores the defaults.
+func (c *Client) SetAssetUploadOptions(opts *AssetUploadOptions) {
+	c.assetOptions = opts
+}
+
+// assetUploadOptions returns the asset upload options of the client, with defaults filled in.
+func (c *Client) assetUploadOptions() AssetUploadOptions {
+	opts := AssetUploadOptions{PollInterval: defaultAssetPollInterval, Timeout: defaultAssetDeliveryTimeout}
+
+	if c.assetOptions != nil && c.assetOptions.PollInterval > 0 {
+		opts.PollInterval = c.assetOptions.PollInterval
+	}
+
+	if c.assetOptions != nil && c.assetOptions.Timeout > 0 {
+		opts.Timeout = c.assetOptions.Timeout
+	}
+
+	return opts
+}
+
+// assetChecksum computes the MD5 checksum App Store Connect expects when committing an asset.
+func assetChecksum(file io.ReaderAt, size int64) (string, error) {
+	h := md5.New() // nolint: gosec
+	if _, err := io.Copy(h, io.NewSectionReader(file, 0, size)); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// waitForAssetDelivery polls the delivery state of an asset until it is COMPLETE or FAILED. It
+// returns ErrAssetDeliveryTimeout if opts.Timeout elapses first.
+func waitForAssetDelivery(ctx context.Context, id string, opts AssetUploadOptions, get func(ctx context.Context) (*AppMediaAssetState, error)) error {
+	parent := ctx
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	var last string
+
+	timedOut := func() error {
+		if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrAssetDeliveryTimeout{ID: id, State: last, Timeout: opts.Timeout}
+		}
+
+		return ctx.Err()
+	}
+
+	for {
+		state, err := get(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return timedOut()
+			}
+
+			return err
+		}
+
+		if state != nil && state.State != nil {
+			last = *state.State
+
+			switch last {
+			case AppMediaAssetStateComplete:
+				return nil
+			case AppMediaAssetStateFailed:
+				return ErrAssetDelivery{ID: id, Errors: state.Errors}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return timedOut()
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanupAsset deletes a reserved asset after a failed upload. It uses its own context, so
+// the reservation is removed even when the upload failed because ctx was canceled.
+func cleanupAsset(remove func(ctx context.Context) (*Response, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), assetCleanupTimeout)
+	defer cancel()
+
+	_, _ = remove(ctx)
+}
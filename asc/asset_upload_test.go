@@ -0,0 +1,236 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAssetServer simulates the reserve, upload, commit and poll cycle for a single asset
+// of the given resource type, such as "appScreenshots".
+type mockAssetServer struct {
+	*routeServer
+
+	finalState string
+	uploadCode int
+
+	uploaded  []byte
+	committed map[string]interface{}
+	created   map[string]interface{}
+	polls     int
+	deleted   bool
+}
+
+func newMockAssetServer(t *testing.T, resource string, finalState string) (*Client, *mockAssetServer) {
+	t.Helper()
+
+	mock := &mockAssetServer{
+		finalState: finalState,
+		uploadCode: http.StatusOK,
+	}
+
+	collection := "/" + resource
+	item := collection + "/1"
+
+	client, server := newRouteServer(t, map[string]http.HandlerFunc{
+		"POST " + collection: func(w http.ResponseWriter, r *http.Request) {
+			mock.created = decodeRequest(r)
+
+			base := "http://" + r.Host
+			fmt.Fprintf(w, `{"data":{"id":"1","type":%q,"attributes":{"uploadOperations":[
+				{"method":"PUT","url":"%s/upload/0","offset":0,"length":4,"requestHeaders":[{"name":"Content-Type","value":"image/png"}]},
+				{"method":"PUT","url":"%s/upload/4","offset":4,"length":4}
+			]}}}`, resource, base, base)
+		},
+		"PUT /upload/0": mock.upload(0),
+		"PUT /upload/4": mock.upload(4),
+		"PATCH " + item: func(w http.ResponseWriter, r *http.Request) {
+			mock.committed = decodeRequest(r)
+
+			fmt.Fprintf(w, `{"data":{"id":"1","type":%q}}`, resource)
+		},
+		"GET " + item: func(w http.ResponseWriter, r *http.Request) {
+			mock.polls++
+
+			state := AppMediaAssetStateUploadComplete
+			if mock.polls > 1 {
+				state = mock.finalState
+			}
+
+			fmt.Fprintf(w, `{"data":{"id":"1","type":%q,"attributes":{"assetDeliveryState":{"state":%q,"errors":[{"code":"IMAGE_INCORRECT_DIMENSIONS","description":"bad size"}]}}}}`, resource, state)
+		},
+		"DELETE " + item: func(w http.ResponseWriter, r *http.Request) {
+			mock.deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+	mock.routeServer = server
+
+	client.SetAssetUploadOptions(&AssetUploadOptions{PollInterval: time.Millisecond})
+
+	return client, mock
+}
+
+// upload returns a handler for the upload of the part of the asset at offset. Parts are uploaded
+// concurrently, so each one is placed at its own offset.
+func (m *mockAssetServer) upload(offset int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if end := offset + len(body); end > len(m.uploaded) {
+			m.uploaded = append(m.uploaded, make([]byte, end-len(m.uploaded))...)
+		}
+
+		copy(m.uploaded[offset:], body)
+		w.WriteHeader(m.uploadCode)
+	}
+}
+
+// decodeRequest decodes the JSON body of a request.
+func decodeRequest(r *http.Request) map[string]interface{} {
+	var req map[string]interface{}
+
+	body, _ := io.ReadAll(r.Body)
+	_ = json.Unmarshal(body, &req)
+
+	return req
+}
+
+func (m *mockAssetServer) createAttributes() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return requestAttributes(m.created)
+}
+
+func (m *mockAssetServer) commitAttributes() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return requestAttributes(m.committed)
+}
+
+func requestAttributes(req map[string]interface{}) map[string]interface{} {
+	data, _ := req["data"].(map[string]interface{})
+	attributes, _ := data["attributes"].(map[string]interface{})
+
+	return attributes
+}
+
+func TestAssetChecksum(t *testing.T) {
+	t.Parallel()
+
+	checksum, err := assetChecksum(strings.NewReader("contents"), 8)
+	assert.NoError(t, err)
+	assert.Equal(t, "98bf7d8c15784f0a3d63204441e1e2aa", checksum)
+
+	_, err = assetChecksum(bytes.NewReader(nil), 8)
+	assert.NoError(t, err)
+}
+
+func TestDetectMimeType(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "video/quicktime", DetectMimeType("preview.MOV", strings.NewReader("")))
+	assert.Equal(t, "video/mp4", DetectMimeType("preview.mp4", strings.NewReader("")))
+	assert.Equal(t, "video/x-m4v", DetectMimeType("preview.m4v", strings.NewReader("")))
+	assert.Equal(t, "image/png", DetectMimeType("screenshot", strings.NewReader("\x89PNG\r\n\x1a\n")))
+	assert.Equal(t, "application/pdf", DetectMimeType("notes", strings.NewReader("%PDF-1.4")))
+}
+
+func TestPreviewFrameTimeCode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "00:00:05:00", PreviewFrameTimeCode(5*time.Second, 30))
+	assert.Equal(t, "01:02:03:15", PreviewFrameTimeCode(time.Hour+2*time.Minute+3*time.Second+500*time.Millisecond, 30))
+	assert.Equal(t, "00:00:01:00", PreviewFrameTimeCode(1500*time.Millisecond, 0))
+	assert.Equal(t, "00:00:00:00", PreviewFrameTimeCode(-time.Second, 30))
+}
+
+func TestErrAssetDelivery(t *testing.T) {
+	t.Parallel()
+
+	err := ErrAssetDelivery{
+		ID: "1",
+		Errors: []AppMediaStateError{
+			{Code: String("IMAGE_INCORRECT_DIMENSIONS"), Description: String("bad size")},
+			{},
+		},
+	}
+
+	assert.Equal(t, "asset 1 failed processing: IMAGE_INCORRECT_DIMENSIONS: bad size; : ", err.Error())
+
+	var stateErr AppMediaStateError
+
+	assert.True(t, errors.As(fmt.Errorf("upload: %w", err), &stateErr))
+	assert.Equal(t, "IMAGE_INCORRECT_DIMENSIONS", *stateErr.Code)
+	assert.NoError(t, ErrAssetDelivery{ID: "1"}.Unwrap())
+}
+
+func TestWaitForAssetDeliveryCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := waitForAssetDelivery(ctx, "1", AssetUploadOptions{PollInterval: time.Hour, Timeout: time.Hour}, func(ctx context.Context) (*AppMediaAssetState, error) {
+		cancel()
+
+		return &AppMediaAssetState{State: String(AppMediaAssetStateUploadComplete)}, nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWaitForAssetDeliveryTimeout(t *testing.T) {
+	t.Parallel()
+
+	err := waitForAssetDelivery(context.Background(), "1", AssetUploadOptions{PollInterval: time.Millisecond, Timeout: 20 * time.Millisecond}, func(ctx context.Context) (*AppMediaAssetState, error) {
+		return &AppMediaAssetState{State: String(AppMediaAssetStateUploadComplete)}, nil
+	})
+
+	assert.Equal(t, ErrAssetDeliveryTimeout{ID: "1", State: AppMediaAssetStateUploadComplete, Timeout: 20 * time.Millisecond}, err)
+	assert.Equal(t, "asset 1 was not processed within 20ms, last state was UPLOAD_COMPLETE", err.Error())
+}
+
+func TestSetAssetUploadOptions(t *testing.T) {
+	t.Parallel()
+
+	defaults := AssetUploadOptions{PollInterval: defaultAssetPollInterval, Timeout: defaultAssetDeliveryTimeout}
+
+	client := NewClient(nil)
+	assert.Equal(t, defaults, client.assetUploadOptions())
+
+	client.SetAssetUploadOptions(&AssetUploadOptions{PollInterval: time.Second})
+	assert.Equal(t, AssetUploadOptions{PollInterval: time.Second, Timeout: defaultAssetDeliveryTimeout}, client.assetUploadOptions())
+
+	client.SetAssetUploadOptions(nil)
+	assert.Equal(t, defaults, client.assetUploadOptions())
+}
@@ -21,12 +21,14 @@ along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
 package asc
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go/v4"
@@ -38,47 +40,144 @@ var ErrMissingPEM = errors.New("no PEM blob found")
 // ErrInvalidPrivateKey happens when a key cannot be parsed as a ECDSA PKCS8 private key.
 var ErrInvalidPrivateKey = errors.New("key could not be parsed as a valid ecdsa.PrivateKey")
 
-// AuthTransport is an http.RoundTripper implementation that stores the JWT created.
-// If the token expires, the Rotate function should be called to update the stored token.
+// ErrInvalidTokenLifetime happens when a token is configured to expire immediately, or later than
+// the 20 minutes App Store Connect accepts.
+var ErrInvalidTokenLifetime = errors.New("token lifetime must be greater than zero and at most 20 minutes")
+
+// ErrInvalidSigner happens when a crypto.Signer does not hold a P-256 ECDSA key, which App Store
+// Connect requires to sign tokens with ES256.
+var ErrInvalidSigner = errors.New("signer must hold a P-256 ecdsa key")
+
+const (
+	tokenAudience        = "appstoreconnect-v1"
+	maxTokenLifetime     = 20 * time.Minute
+	defaultRefreshMargin = time.Minute
+)
+
+// defaultAuthTransport is used by an AuthTransport without a Transport.
+var defaultAuthTransport = newTransport()
+
+// TokenSource supplies the bearer tokens that an AuthTransport authorizes requests with. Token is
+// called for every request, and must be safe for concurrent use.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// AuthTransport is an http.RoundTripper implementation that sets the Authorization header of
+// every request to a token from its TokenSource. It is safe for concurrent use.
 type AuthTransport struct {
-	Transport    http.RoundTripper
-	jwtGenerator jwtGenerator
+	Transport http.RoundTripper
+	source    TokenSource
 }
 
-type jwtGenerator interface {
-	Token() (string, error)
-	IsValid() bool
+// NewAuthTransport returns a new AuthTransport that authorizes requests with tokens from source.
+func NewAuthTransport(source TokenSource) *AuthTransport {
+	return &AuthTransport{
+		Transport: newTransport(),
+		source:    source,
+	}
 }
 
-type standardJWTGenerator struct {
+// TokenOption customizes the tokens signed by a JWTTokenSource.
+type TokenOption func(*JWTTokenSource)
+
+// WithScope limits tokens to the given requests, each made of an HTTP method and a path, such as
+// "GET /v1/apps?filter[platform]=IOS". App Store Connect rejects any other request made with them.
+func WithScope(scope ...string) TokenOption {
+	return func(s *JWTTokenSource) {
+		s.scope = scope
+	}
+}
+
+// WithRefreshMargin sets how long before it expires a token is replaced by a new one, so that a
+// token never expires while a request is in flight. Defaults to 1 minute. A margin as long as
+// the lifetime of the token signs a new token for every request.
+func WithRefreshMargin(margin time.Duration) TokenOption {
+	return func(s *JWTTokenSource) {
+		s.refreshMargin = margin
+	}
+}
+
+// JWTTokenSource is a TokenSource that signs JSON Web Tokens for the App Store Connect API with
+// a crypto.Signer, and reuses each token until shortly before it expires. The signer can be an
+// *ecdsa.PrivateKey, or a key held by a key management service or hardware security module. It
+// is safe for concurrent use.
+type JWTTokenSource struct {
 	keyID          string
 	issuerID       string
+	scope          []string
 	expireDuration time.Duration
-	privateKey     *ecdsa.PrivateKey
+	refreshMargin  time.Duration
+	signer         crypto.Signer
+	now            func() time.Time
 
-	token string
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewJWTTokenSource returns a new JWTTokenSource for the API key with the given ID, whose tokens
+// are valid for expireDuration, which must be at most 20 minutes. If issuerID is empty, the key is
+// an individual API key, and its tokens act on behalf of the user who created it.
+func NewJWTTokenSource(keyID string, issuerID string, expireDuration time.Duration, signer crypto.Signer, opts ...TokenOption) (*JWTTokenSource, error) {
+	if expireDuration <= 0 || expireDuration > maxTokenLifetime {
+		return nil, ErrInvalidTokenLifetime
+	}
+
+	if key, ok := signer.Public().(*ecdsa.PublicKey); !ok || key.Curve.Params().BitSize != 256 {
+		return nil, ErrInvalidSigner
+	}
+
+	s := &JWTTokenSource{
+		keyID:          keyID,
+		issuerID:       issuerID,
+		expireDuration: expireDuration,
+		refreshMargin:  defaultRefreshMargin,
+		signer:         signer,
+		now:            time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
 }
 
 // NewTokenConfig returns a new AuthTransport instance that customizes the Authentication header of the request during transport.
 // It can be customized further by supplying a custom http.RoundTripper instance to the Transport field.
-func NewTokenConfig(keyID string, issuerID string, expireDuration time.Duration, privateKey []byte) (*AuthTransport, error) {
+//
+// Tokens are valid for expireDuration, which must be at most 20 minutes, and are signed again
+// shortly before they expire.
+func NewTokenConfig(keyID string, issuerID string, expireDuration time.Duration, privateKey []byte, opts ...TokenOption) (*AuthTransport, error) {
+	return newTokenConfig(keyID, issuerID, expireDuration, privateKey, opts)
+}
+
+// NewIndividualTokenConfig returns a new AuthTransport instance like NewTokenConfig, for an
+// individual API key. Tokens of individual keys have no issuer, and act on behalf of the user
+// who created the key.
+func NewIndividualTokenConfig(keyID string, expireDuration time.Duration, privateKey []byte, opts ...TokenOption) (*AuthTransport, error) {
+	return newTokenConfig(keyID, "", expireDuration, privateKey, opts)
+}
+
+func newTokenConfig(keyID string, issuerID string, expireDuration time.Duration, privateKey []byte, opts []TokenOption) (*AuthTransport, error) {
+	if expireDuration <= 0 || expireDuration > maxTokenLifetime {
+		return nil, ErrInvalidTokenLifetime
+	}
+
 	key, err := parsePrivateKey(privateKey)
 	if err != nil {
 		return nil, err
 	}
 
-	gen := &standardJWTGenerator{
-		keyID:          keyID,
-		issuerID:       issuerID,
-		privateKey:     key,
-		expireDuration: expireDuration,
+	source, err := NewJWTTokenSource(keyID, issuerID, expireDuration, key, opts...)
+	if err != nil {
+		return nil, err
 	}
-	_, err = gen.Token()
 
-	return &AuthTransport{
-		Transport:    newTransport(),
-		jwtGenerator: gen,
-	}, err
+	_, err = source.Token()
+
+	return NewAuthTransport(source), err
 }
 
 func parsePrivateKey(blob []byte) (*ecdsa.PrivateKey, error) {
@@ -100,8 +199,8 @@ func parsePrivateKey(blob []byte) (*ecdsa.PrivateKey, error) {
 }
 
 // RoundTrip implements the http.RoundTripper interface to set the Authorization header.
-func (t AuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	token, err := t.jwtGenerator.Token()
+func (t *AuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token()
 	if err != nil {
 		return nil, err
 	}
@@ -118,56 +217,66 @@ func (t *AuthTransport) Client() *http.Client {
 
 func (t *AuthTransport) transport() http.RoundTripper {
 	if t.Transport == nil {
-		t.Transport = newTransport()
+		return defaultAuthTransport
 	}
 
 	return t.Transport
 }
 
-func (g *standardJWTGenerator) Token() (string, error) {
-	if g.IsValid() {
-		return g.token, nil
+// Token returns the current token, or signs a new one if it is about to expire.
+func (s *JWTTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.valid(now) {
+		return s.token, nil
 	}
 
-	t := jwt.NewWithClaims(jwt.SigningMethodES256, g.claims())
-	t.Header["kid"] = g.keyID
+	expiresAt := now.Add(s.expireDuration)
+
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, s.claims(now, expiresAt))
+	t.Header["kid"] = s.keyID
 
-	token, err := t.SignedString(g.privateKey)
+	token, err := t.SignedString(s.signer)
 	if err != nil {
 		return "", err
 	}
 
-	g.token = token
+	s.token = token
+	s.expiresAt = expiresAt
 
 	return token, nil
 }
 
-func (g *standardJWTGenerator) IsValid() bool {
-	if g.token == "" {
-		return false
-	}
-
-	parsed, err := jwt.Parse(
-		g.token,
-		jwt.KnownKeyfunc(jwt.SigningMethodES256, g.privateKey),
-		jwt.WithAudience("appstoreconnect-v1"),
-		jwt.WithIssuer(g.issuerID),
-	)
-	if err != nil {
-		return false
-	}
+// valid reports whether the current token is still valid at now plus the refresh margin. It
+// must be called with s.mu held.
+func (s *JWTTokenSource) valid(now time.Time) bool {
+	return s.token != "" && now.Add(s.refreshMargin).Before(s.expiresAt)
+}
 
-	return parsed.Valid
+// tokenClaims are the claims of a token for the App Store Connect API.
+type tokenClaims struct {
+	jwt.StandardClaims
+	Scope []string `json:"scope,omitempty"`
 }
 
-func (g *standardJWTGenerator) claims() jwt.Claims {
-	expiry := time.Now().Add(g.expireDuration)
+func (s *JWTTokenSource) claims(issuedAt time.Time, expiresAt time.Time) jwt.Claims {
+	claims := tokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Audience:  jwt.ClaimStrings{tokenAudience},
+			Issuer:    s.issuerID,
+			IssuedAt:  jwt.At(issuedAt),
+			ExpiresAt: jwt.At(expiresAt),
+		},
+		Scope: s.scope,
+	}
 
-	return jwt.StandardClaims{
-		Audience:  jwt.ClaimStrings{"appstoreconnect-v1"},
-		Issuer:    g.issuerID,
-		ExpiresAt: jwt.At(expiry),
+	if s.issuerID == "" {
+		claims.Subject = "user"
 	}
+
+	return claims
 }
 
 func newTransport() http.RoundTripper {
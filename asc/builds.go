@@ -57,6 +57,18 @@ type BuildAttributes struct {
 	Version                 *string     `json:"version,omitempty"`
 }
 
+// Possible values of BuildAttributes.ProcessingState.
+const (
+	// BuildProcessingStateProcessing is the state of a build that App Store Connect is still processing.
+	BuildProcessingStateProcessing = "PROCESSING"
+	// BuildProcessingStateFailed is the state of a build that App Store Connect failed to process.
+	BuildProcessingStateFailed = "FAILED"
+	// BuildProcessingStateInvalid is the state of a build that App Store Connect rejected as invalid.
+	BuildProcessingStateInvalid = "INVALID"
+	// BuildProcessingStateValid is the state of a build that is ready to be tested or submitted.
+	BuildProcessingStateValid = "VALID"
+)
+
 // BuildRelationships defines model for Build.Relationships
 //
 // https://developer.apple.com/documentation/appstoreconnectapi/build/relationships
@@ -330,7 +342,7 @@ func (s *BuildsService) UpdateBuild(ctx context.Context, id string, expired *boo
 
 	url := fmt.Sprintf("builds/%s", id)
 	res := new(BuildResponse)
-	resp, err := s.client.post(ctx, url, newRequestBody(req), res)
+	resp, err := s.client.patch(ctx, url, newRequestBody(req), res)
 
 	return res, resp, err
 }
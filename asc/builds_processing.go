@@ -0,0 +1,245 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+const (
+	defaultProcessingInitialInterval = 10 * time.Second
+	defaultProcessingMaxInterval     = time.Minute
+)
+
+// ErrMissingBuildLookup happens when WaitForProcessing is given neither a build ID nor an app ID
+// and version to find the build with.
+var ErrMissingBuildLookup = errors.New("a build ID, or an app ID and version, is required to find the build")
+
+// ErrBuildProcessingFailed happens when App Store Connect finished processing a build without
+// accepting it.
+type ErrBuildProcessingFailed struct {
+	BuildID string
+	// State is BuildProcessingStateInvalid or BuildProcessingStateFailed.
+	State string
+}
+
+func (e ErrBuildProcessingFailed) Error() string {
+	return fmt.Sprintf("build %s finished processing in state %s", e.BuildID, e.State)
+}
+
+// ErrBuildProcessingTimeout happens when a build is not processed within
+// WaitForProcessingOptions.Timeout.
+type ErrBuildProcessingTimeout struct {
+	// BuildID is empty if the build was never found.
+	BuildID string
+	// State is the last processing state that was observed, if any.
+	State   string
+	Timeout time.Duration
+}
+
+func (e ErrBuildProcessingTimeout) Error() string {
+	if e.BuildID == "" {
+		return fmt.Sprintf("build was not found within %s", e.Timeout)
+	}
+
+	return fmt.Sprintf("build %s was not processed within %s, last state was %s", e.BuildID, e.Timeout, e.State)
+}
+
+// BuildProcessingEvent describes a change in the processing state of a build.
+type BuildProcessingEvent struct {
+	BuildID string
+	// From is the previous processing state, or empty when the build was just found.
+	From string
+	To   string
+	Time time.Time
+}
+
+// WaitForProcessingOptions are options for BuildsService.WaitForProcessing.
+type WaitForProcessingOptions struct {
+	// BuildID is the build to wait for. If empty, the most recently uploaded build matching
+	// AppID, Version, PreReleaseVersion and Platform is waited for, which may take a few minutes
+	// to appear after an upload.
+	BuildID string
+	AppID   string
+	// Version is the build number of the build, or CFBundleVersion.
+	Version string
+	// PreReleaseVersion is the version of the build, or CFBundleShortVersionString.
+	PreReleaseVersion string
+	Platform          Platform
+	// InitialInterval is the delay between the first and the second poll. The first poll is made
+	// right away, and delays grow exponentially with jitter up to MaxInterval. Defaults to 10
+	// seconds.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between two polls. Defaults to 1 minute.
+	MaxInterval time.Duration
+	// Timeout bounds the whole wait. Zero means the wait is only bounded by the context.
+	Timeout time.Duration
+	// Events, if set, receives an event every time the processing state of the build changes.
+	// Sends block until the event is received or the context is done, and the channel is not
+	// closed by WaitForProcessing.
+	Events chan<- BuildProcessingEvent
+}
+
+// WaitForProcessing polls a build until App Store Connect has processed it, and returns the build
+// once its processing state is BuildProcessingStateValid. It returns ErrBuildProcessingFailed if
+// the build is invalid or failed to process, and ErrBuildProcessingTimeout if opts.Timeout elapses
+// first.
+func (s *BuildsService) WaitForProcessing(ctx context.Context, opts WaitForProcessingOptions) (*Build, error) {
+	if opts.BuildID == "" && (opts.AppID == "" || opts.Version == "") {
+		return nil, ErrMissingBuildLookup
+	}
+
+	parent := ctx
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = defaultProcessingInitialInterval
+	b.MaxInterval = defaultProcessingMaxInterval
+	b.MaxElapsedTime = 0
+
+	if opts.InitialInterval > 0 {
+		b.InitialInterval = opts.InitialInterval
+	}
+
+	if opts.MaxInterval > 0 {
+		b.MaxInterval = opts.MaxInterval
+	}
+
+	b.Reset()
+
+	var (
+		buildID = opts.BuildID
+		state   string
+	)
+
+	timedOut := func() error {
+		if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrBuildProcessingTimeout{BuildID: buildID, State: state, Timeout: opts.Timeout}
+		}
+
+		return ctx.Err()
+	}
+
+	for {
+		build, found, err := s.processingBuild(ctx, buildID, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, timedOut()
+			}
+
+			return nil, err
+		}
+
+		if found {
+			buildID = build.ID
+
+			var next string
+			if build.Attributes != nil && build.Attributes.ProcessingState != nil {
+				next = *build.Attributes.ProcessingState
+			}
+
+			if next != state {
+				event := BuildProcessingEvent{BuildID: buildID, From: state, To: next, Time: time.Now()}
+				state = next
+
+				if err := sendProcessingEvent(ctx, opts.Events, event); err != nil {
+					return nil, timedOut()
+				}
+			}
+
+			switch state {
+			case BuildProcessingStateValid:
+				return &build, nil
+			case BuildProcessingStateInvalid, BuildProcessingStateFailed:
+				return nil, ErrBuildProcessingFailed{BuildID: buildID, State: state}
+			}
+		}
+
+		timer := time.NewTimer(b.NextBackOff())
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil, timedOut()
+		case <-timer.C:
+		}
+	}
+}
+
+// processingBuild fetches the build being waited for, or finds it by version. It reports false
+// if no build matches yet.
+func (s *BuildsService) processingBuild(ctx context.Context, buildID string, opts WaitForProcessingOptions) (Build, bool, error) {
+	if buildID != "" {
+		res, _, err := s.GetBuild(ctx, buildID, nil)
+		if err != nil {
+			return Build{}, false, err
+		}
+
+		return res.Data, true, nil
+	}
+
+	query := &ListBuildsQuery{
+		FilterApp:     []string{opts.AppID},
+		FilterVersion: []string{opts.Version},
+		Sort:          []string{"-uploadedDate"},
+		Limit:         1,
+	}
+
+	if opts.PreReleaseVersion != "" {
+		query.FilterPreReleaseVersionVersion = []string{opts.PreReleaseVersion}
+	}
+
+	if opts.Platform != "" {
+		query.FilterPreReleaseVersionPlatform = []string{string(opts.Platform)}
+	}
+
+	res, _, err := s.ListBuilds(ctx, query)
+	if err != nil || len(res.Data) == 0 {
+		return Build{}, false, err
+	}
+
+	return res.Data[0], true, nil
+}
+
+func sendProcessingEvent(ctx context.Context, events chan<- BuildProcessingEvent, event BuildProcessingEvent) error {
+	if events == nil {
+		return nil
+	}
+
+	select {
+	case events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
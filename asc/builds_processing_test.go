@@ -0,0 +1,142 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockProcessingServer serves build "b1", which is missing from the first build list it
+// returns, and then moves through the given processing states, one per poll.
+func newMockProcessingServer(t *testing.T, states ...string) (*Client, *routeServer) {
+	t.Helper()
+
+	var (
+		listed bool
+		served int
+	)
+
+	state := func() string {
+		i := served
+		if i >= len(states) {
+			i = len(states) - 1
+		}
+
+		served++
+
+		return states[i]
+	}
+
+	return newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /builds": func(w http.ResponseWriter, r *http.Request) {
+			if !listed {
+				listed = true
+
+				fmt.Fprint(w, `{"data":[]}`)
+
+				return
+			}
+
+			fmt.Fprintf(w, `{"data":[{"id":"b1","type":"builds","attributes":{"processingState":%q}}]}`, state())
+		},
+		"GET /builds/b1": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data":{"id":"b1","type":"builds","attributes":{"processingState":%q}}}`, state())
+		},
+	})
+}
+
+func TestWaitForProcessing(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockProcessingServer(t, BuildProcessingStateProcessing, BuildProcessingStateProcessing, BuildProcessingStateValid)
+
+	events := make(chan BuildProcessingEvent, 10)
+
+	build, err := client.Builds.WaitForProcessing(context.Background(), WaitForProcessingOptions{
+		AppID:             "1",
+		Version:           "42",
+		PreReleaseVersion: "1.0",
+		Platform:          PlatformIOS,
+		InitialInterval:   time.Millisecond,
+		MaxInterval:       time.Millisecond,
+		Events:            events,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "b1", build.ID)
+	assert.Equal(t, []string{"GET /builds", "GET /builds", "GET /builds/b1", "GET /builds/b1"}, server.requests())
+
+	close(events)
+
+	var transitions []string
+	for event := range events {
+		assert.Equal(t, "b1", event.BuildID)
+		transitions = append(transitions, event.From+"->"+event.To)
+	}
+
+	assert.Equal(t, []string{"->PROCESSING", "PROCESSING->VALID"}, transitions)
+}
+
+func TestWaitForProcessingFailed(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newMockProcessingServer(t, BuildProcessingStateInvalid)
+
+	_, err := client.Builds.WaitForProcessing(context.Background(), WaitForProcessingOptions{
+		BuildID:         "b1",
+		InitialInterval: time.Millisecond,
+	})
+	assert.Equal(t, ErrBuildProcessingFailed{BuildID: "b1", State: BuildProcessingStateInvalid}, err)
+}
+
+func TestWaitForProcessingTimeout(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newMockProcessingServer(t, BuildProcessingStateProcessing)
+
+	_, err := client.Builds.WaitForProcessing(context.Background(), WaitForProcessingOptions{
+		BuildID:         "b1",
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+		Timeout:         50 * time.Millisecond,
+	})
+
+	var timeout ErrBuildProcessingTimeout
+
+	assert.True(t, errors.As(err, &timeout))
+	assert.Equal(t, BuildProcessingStateProcessing, timeout.State)
+	assert.EqualError(t, err, "build b1 was not processed within 50ms, last state was PROCESSING")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.Builds.WaitForProcessing(ctx, WaitForProcessingOptions{BuildID: "b1", Timeout: time.Minute})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = client.Builds.WaitForProcessing(ctx, WaitForProcessingOptions{AppID: "1"})
+	assert.Equal(t, ErrMissingBuildLookup, err)
+}
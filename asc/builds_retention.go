@@ -0,0 +1,473 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BuildRetentionPolicy decides which TestFlight builds of an app are expired ahead of the 90 days
+// after which App Store Connect expires them. A build is expired once it is older than OlderThan,
+// unless another rule keeps it. Builds that are still processing or already expired are left
+// alone.
+type BuildRetentionPolicy struct {
+	// AppID is the app whose builds are expired.
+	AppID string
+	// KeepLatest is the number of most recently uploaded builds kept for each prerelease version.
+	KeepLatest int
+	// KeepAppStoreVersions keeps the builds attached to an App Store version.
+	KeepAppStoreVersions bool
+	// OlderThan expires builds uploaded more than this long ago. If zero, no build is expired.
+	OlderThan time.Duration
+}
+
+// BuildExpiration is a build that a retention policy expires.
+type BuildExpiration struct {
+	BuildID string
+	// Version is the build number.
+	Version string
+	// PrereleaseVersion is the version string of the prerelease version the build belongs to.
+	PrereleaseVersion string
+	UploadedDate      time.Time
+	// BetaGroups are the names of the beta groups that lose access to the build.
+	BetaGroups []string
+}
+
+func (e BuildExpiration) String() string {
+	s := fmt.Sprintf("%s (%s) %s", e.PrereleaseVersion, e.Version, e.BuildID)
+
+	if !e.UploadedDate.IsZero() {
+		s += ", uploaded " + e.UploadedDate.Format("2006-01-02")
+	}
+
+	if len(e.BetaGroups) > 0 {
+		s += fmt.Sprintf(" [%s]", strings.Join(e.BetaGroups, ", "))
+	}
+
+	return s
+}
+
+// RetainedBuild is a build that a retention policy keeps, and why.
+type RetainedBuild struct {
+	BuildID           string
+	Version           string
+	PrereleaseVersion string
+	Reason            string
+}
+
+// BetaGroupBuildLoss describes the builds a beta group loses access to when a retention policy is
+// applied.
+type BetaGroupBuildLoss struct {
+	BetaGroupID string
+	Name        string
+	// Builds are the IDs of the builds the group loses access to.
+	Builds []string
+	// Remaining is the number of builds of the group that are not expired afterwards. Testers of
+	// a group with no remaining builds have nothing left to test.
+	Remaining int
+}
+
+// BuildRetentionPlan lists the builds a retention policy expires and keeps, and the beta groups
+// that lose access to builds as a result. It is returned by BuildsService.PlanBuildRetention and
+// applied with BuildsService.ApplyBuildRetention.
+type BuildRetentionPlan struct {
+	AppID      string
+	Expire     []BuildExpiration
+	Keep       []RetainedBuild
+	BetaGroups []BetaGroupBuildLoss
+}
+
+// String formats the plan for review, with one line per expired build followed by one line per
+// beta group that loses access to builds.
+func (p *BuildRetentionPlan) String() string {
+	var b strings.Builder
+
+	for _, expiration := range p.Expire {
+		fmt.Fprintf(&b, "expire %s\n", expiration)
+	}
+
+	for _, loss := range p.BetaGroups {
+		fmt.Fprintf(&b, "beta group %s loses %d builds, %d remaining\n", loss.Name, len(loss.Builds), loss.Remaining)
+	}
+
+	return b.String()
+}
+
+// BuildExpirationResult is the outcome of expiring one build.
+type BuildExpirationResult struct {
+	Expiration BuildExpiration
+	// Applied reports whether the build was expired. It is false in dry-run mode and when Err is
+	// set.
+	Applied bool
+	Err     error
+}
+
+// BuildRetentionReport holds the result of every build expired by a retention policy, in the
+// order of the plan it applied.
+type BuildRetentionReport struct {
+	Plan    *BuildRetentionPlan
+	Results []BuildExpirationResult
+}
+
+// Failed returns the results of the builds that could not be expired.
+func (r *BuildRetentionReport) Failed() []BuildExpirationResult {
+	var failed []BuildExpirationResult
+
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Err returns the error of the first build that could not be expired, or nil if none failed.
+func (r *BuildRetentionReport) Err() error {
+	for _, result := range r.Results {
+		if result.Err != nil {
+			return fmt.Errorf("expire build %s: %w", result.Expiration.BuildID, result.Err)
+		}
+	}
+
+	return nil
+}
+
+// String formats the report with one line per build and its outcome.
+func (r *BuildRetentionReport) String() string {
+	var b strings.Builder
+
+	for _, result := range r.Results {
+		status := "planned"
+
+		switch {
+		case result.Err != nil:
+			status = result.Err.Error()
+		case result.Applied:
+			status = "ok"
+		}
+
+		fmt.Fprintf(&b, "expire %s: %s\n", result.Expiration, status)
+	}
+
+	return b.String()
+}
+
+// ExpireBuilds expires the builds of an app that a BuildRetentionPolicy does not keep, and
+// reports the outcome of every build. If dryRun is true, the builds are reported without being
+// expired. Errors of single builds do not stop the others, and are reported in the
+// BuildRetentionReport.
+func (s *BuildsService) ExpireBuilds(ctx context.Context, policy BuildRetentionPolicy, dryRun bool) (*BuildRetentionReport, error) {
+	plan, err := s.PlanBuildRetention(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		report := &BuildRetentionReport{Plan: plan, Results: make([]BuildExpirationResult, len(plan.Expire))}
+		for i, expiration := range plan.Expire {
+			report.Results[i].Expiration = expiration
+		}
+
+		return report, nil
+	}
+
+	return s.ApplyBuildRetention(ctx, plan), nil
+}
+
+// retentionBuild is a build of the app being planned, and what it is attached to.
+type retentionBuild struct {
+	build             Build
+	prereleaseID      string
+	prereleaseVersion string
+	uploaded          time.Time
+	appStoreVersion   bool
+	groups            []string
+}
+
+// PlanBuildRetention applies a BuildRetentionPolicy to the builds of an app without changing
+// anything. It lists the builds of the app with ListBuildsForApp, then walks its prerelease
+// versions, App Store versions and beta groups to find what each build is attached to.
+func (s *BuildsService) PlanBuildRetention(ctx context.Context, policy BuildRetentionPolicy) (*BuildRetentionPlan, error) {
+	builds, err := s.retentionBuilds(ctx, policy.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.retentionBetaGroups(ctx, policy.AppID, builds)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy.KeepAppStoreVersions {
+		if err := s.retentionAppStoreVersions(ctx, policy.AppID, builds); err != nil {
+			return nil, err
+		}
+	}
+
+	live := make([]*retentionBuild, 0, len(builds))
+
+	for _, build := range builds {
+		attributes := build.build.Attributes
+		if attributes == nil || (attributes.Expired != nil && *attributes.Expired) {
+			continue
+		}
+
+		if attributes.ProcessingState != nil && *attributes.ProcessingState != BuildProcessingStateValid {
+			continue
+		}
+
+		live = append(live, build)
+	}
+
+	sort.SliceStable(live, func(i, j int) bool { return live[i].uploaded.After(live[j].uploaded) })
+
+	plan := &BuildRetentionPlan{AppID: policy.AppID}
+	expired := make(map[string]bool)
+	kept := make(map[string]int)
+	cutoff := time.Now().Add(-policy.OlderThan)
+
+	for _, build := range live {
+		reason := ""
+
+		switch {
+		case kept[build.prereleaseID] < policy.KeepLatest:
+			reason = "one of the latest builds of " + build.prereleaseVersion
+			kept[build.prereleaseID]++
+		case build.appStoreVersion:
+			reason = "attached to an App Store version"
+		case policy.OlderThan <= 0:
+			reason = "no age limit"
+		case build.uploaded.IsZero():
+			reason = "unknown upload date"
+		case !build.uploaded.Before(cutoff):
+			reason = "uploaded " + build.uploaded.Format("2006-01-02")
+		}
+
+		version := ""
+		if build.build.Attributes.Version != nil {
+			version = *build.build.Attributes.Version
+		}
+
+		if reason != "" {
+			plan.Keep = append(plan.Keep, RetainedBuild{
+				BuildID:           build.build.ID,
+				Version:           version,
+				PrereleaseVersion: build.prereleaseVersion,
+				Reason:            reason,
+			})
+
+			continue
+		}
+
+		expiration := BuildExpiration{
+			BuildID:           build.build.ID,
+			Version:           version,
+			PrereleaseVersion: build.prereleaseVersion,
+			UploadedDate:      build.uploaded,
+		}
+
+		for _, groupID := range build.groups {
+			expiration.BetaGroups = append(expiration.BetaGroups, groups[groupID].Name)
+		}
+
+		sort.Strings(expiration.BetaGroups)
+
+		expired[build.build.ID] = true
+		plan.Expire = append(plan.Expire, expiration)
+	}
+
+	plan.BetaGroups = betaGroupBuildLosses(groups, live, expired)
+
+	return plan, nil
+}
+
+// retentionBuilds returns the builds of the app by ID, along with their prerelease versions.
+func (s *BuildsService) retentionBuilds(ctx context.Context, appID string) (map[string]*retentionBuild, error) {
+	res, _, err := NewPager[Build](s.client, func(ctx context.Context) (*BuildsResponse, *Response, error) {
+		return s.ListBuildsForApp(ctx, appID, &ListBuildsForAppQuery{
+			FieldsBuilds: []string{"expired", "expirationDate", "processingState", "uploadedDate", "version"},
+			Limit:        200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	builds := make(map[string]*retentionBuild, len(res.Data))
+
+	for _, build := range res.Data {
+		current := &retentionBuild{build: build}
+		if build.Attributes != nil && build.Attributes.UploadedDate != nil {
+			current.uploaded = build.Attributes.UploadedDate.Time
+		}
+
+		builds[build.ID] = current
+	}
+
+	versions, _, err := NewPager[PrereleaseVersion](s.client, func(ctx context.Context) (*PrereleaseVersionsResponse, *Response, error) {
+		return s.client.TestFlight.ListPrereleaseVersionsForApp(ctx, appID, &ListPrereleaseVersionsForAppQuery{
+			FieldsPreReleaseVersions: []string{"platform", "version"},
+			Limit:                    200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions.Data {
+		label := version.ID
+		if version.Attributes != nil && version.Attributes.Version != nil {
+			label = *version.Attributes.Version
+		}
+
+		res, _, err := NewPager[Build](s.client, func(ctx context.Context) (*BuildsResponse, *Response, error) {
+			return s.client.TestFlight.ListBuildsForPrereleaseVersion(ctx, version.ID, &ListBuildsForPrereleaseVersionQuery{
+				FieldsBuilds: []string{"version"},
+				Limit:        200,
+			})
+		}).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, build := range res.Data {
+			if current, ok := builds[build.ID]; ok {
+				current.prereleaseID = version.ID
+				current.prereleaseVersion = label
+			}
+		}
+	}
+
+	return builds, nil
+}
+
+// retentionAppStoreVersions marks the builds attached to an App Store version of the app.
+func (s *BuildsService) retentionAppStoreVersions(ctx context.Context, appID string, builds map[string]*retentionBuild) error {
+	versions, _, err := NewPager[AppStoreVersion](s.client, func(ctx context.Context) (*AppStoreVersionsResponse, *Response, error) {
+		return s.client.Apps.ListAppStoreVersionsForApp(ctx, appID, &ListAppStoreVersionsQuery{
+			FieldsAppStoreVersions: []string{"versionString"},
+			Limit:                  200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions.Data {
+		res, _, err := s.client.Apps.GetBuildIDForAppStoreVersion(ctx, version.ID)
+		if err = ignoreNotFound(err); err != nil {
+			return err
+		}
+
+		if res == nil {
+			continue
+		}
+
+		if build, ok := builds[res.Data.ID]; ok {
+			build.appStoreVersion = true
+		}
+	}
+
+	return nil
+}
+
+// retentionBetaGroups returns the beta groups of the app by ID, and records the groups each build
+// is attached to.
+func (s *BuildsService) retentionBetaGroups(ctx context.Context, appID string, builds map[string]*retentionBuild) (map[string]*BetaGroupBuildLoss, error) {
+	res, _, err := NewPager[BetaGroup](s.client, func(ctx context.Context) (*BetaGroupsResponse, *Response, error) {
+		return s.client.TestFlight.ListBetaGroupsForApp(ctx, appID, &ListBetaGroupsForAppQuery{
+			FieldsBetaGroups: []string{"name"},
+			Limit:            200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*BetaGroupBuildLoss, len(res.Data))
+
+	for _, group := range res.Data {
+		groups[group.ID] = &BetaGroupBuildLoss{BetaGroupID: group.ID, Name: group.ID}
+		if group.Attributes != nil && group.Attributes.Name != nil {
+			groups[group.ID].Name = *group.Attributes.Name
+		}
+
+		linkages, _, err := NewPager[RelationshipData](s.client, func(ctx context.Context) (*BetaGroupBuildsLinkagesResponse, *Response, error) {
+			return s.client.TestFlight.ListBuildIDsForBetaGroup(ctx, group.ID, &ListBuildIDsForBetaGroupQuery{Limit: 200})
+		}).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, linkage := range linkages.Data {
+			if build, ok := builds[linkage.ID]; ok {
+				build.groups = append(build.groups, group.ID)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// betaGroupBuildLosses returns the beta groups that lose access to expired builds, ordered by
+// name, with the number of live builds each keeps.
+func betaGroupBuildLosses(groups map[string]*BetaGroupBuildLoss, live []*retentionBuild, expired map[string]bool) []BetaGroupBuildLoss {
+	for _, build := range live {
+		for _, groupID := range build.groups {
+			if expired[build.build.ID] {
+				groups[groupID].Builds = append(groups[groupID].Builds, build.build.ID)
+			} else {
+				groups[groupID].Remaining++
+			}
+		}
+	}
+
+	var losses []BetaGroupBuildLoss
+
+	for _, group := range groups {
+		if len(group.Builds) > 0 {
+			losses = append(losses, *group)
+		}
+	}
+
+	sort.Slice(losses, func(i, j int) bool { return losses[i].Name < losses[j].Name })
+
+	return losses
+}
+
+// ApplyBuildRetention applies a plan returned by PlanBuildRetention, expiring its builds one at a
+// time with UpdateBuild. Errors do not stop the others, and are reported with the build they
+// belong to.
+func (s *BuildsService) ApplyBuildRetention(ctx context.Context, plan *BuildRetentionPlan) *BuildRetentionReport {
+	report := &BuildRetentionReport{Plan: plan, Results: make([]BuildExpirationResult, len(plan.Expire))}
+
+	for i, expiration := range plan.Expire {
+		_, _, err := s.UpdateBuild(ctx, expiration.BuildID, Bool(true), nil, nil)
+		report.Results[i] = BuildExpirationResult{Expiration: expiration, Applied: err == nil, Err: err}
+	}
+
+	return report
+}
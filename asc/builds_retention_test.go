@@ -0,0 +1,150 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockBuildRetentionServer serves app "a1", with builds b0 to b3 of prerelease version 1.0
+// and builds b4 to b6 of prerelease version 1.1. Build b0 has already expired, b1 is attached to
+// an App Store version, b5 was uploaded recently and b6 is still processing. The beta group "QA"
+// (g1) tests b2 and b3, and the beta group "Public" (g2) tests b2.
+func newMockBuildRetentionServer(t *testing.T) (*Client, *[]string) {
+	t.Helper()
+
+	var requests []string
+
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	expire := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+		fmt.Fprint(w, `{"data":{"id":"b2","type":"builds"}}`)
+	}
+
+	client, _ := newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /apps/a1/builds": respond(fmt.Sprintf(`{"data":[
+			{"id":"b0","type":"builds","attributes":{"version":"0","expired":true,"processingState":"VALID","uploadedDate":"2020-01-01T00:00:00Z"}},
+			{"id":"b1","type":"builds","attributes":{"version":"1","expired":false,"processingState":"VALID","uploadedDate":"2020-02-01T00:00:00Z"}},
+			{"id":"b2","type":"builds","attributes":{"version":"2","expired":false,"processingState":"VALID","uploadedDate":"2020-03-01T00:00:00Z"}},
+			{"id":"b3","type":"builds","attributes":{"version":"3","expired":false,"processingState":"VALID","uploadedDate":"2020-04-01T00:00:00Z"}},
+			{"id":"b4","type":"builds","attributes":{"version":"4","expired":false,"processingState":"VALID","uploadedDate":"2020-05-01T00:00:00Z"}},
+			{"id":"b5","type":"builds","attributes":{"version":"5","expired":false,"processingState":"VALID","uploadedDate":%q}},
+			{"id":"b6","type":"builds","attributes":{"version":"6","expired":false,"processingState":"PROCESSING","uploadedDate":%q}}
+		]}`, recent, recent)),
+		"GET /apps/a1/preReleaseVersions": respond(`{"data":[
+			{"id":"p1","type":"preReleaseVersions","attributes":{"version":"1.0","platform":"IOS"}},
+			{"id":"p2","type":"preReleaseVersions","attributes":{"version":"1.1","platform":"IOS"}}
+		]}`),
+		"GET /preReleaseVersions/p1/builds":            respond(`{"data":[{"id":"b0","type":"builds"},{"id":"b1","type":"builds"},{"id":"b2","type":"builds"},{"id":"b3","type":"builds"}]}`),
+		"GET /preReleaseVersions/p2/builds":            respond(`{"data":[{"id":"b4","type":"builds"},{"id":"b5","type":"builds"},{"id":"b6","type":"builds"}]}`),
+		"GET /apps/a1/appStoreVersions":                respond(`{"data":[{"id":"v1","type":"appStoreVersions"},{"id":"v2","type":"appStoreVersions"}]}`),
+		"GET /appStoreVersions/v1/relationships/build": respond(`{"data":{"id":"b1","type":"builds"}}`),
+		"GET /appStoreVersions/v2/relationships/build": notFound,
+		"GET /apps/a1/betaGroups": respond(`{"data":[
+			{"id":"g1","type":"betaGroups","attributes":{"name":"QA"}},
+			{"id":"g2","type":"betaGroups","attributes":{"name":"Public"}}
+		]}`),
+		"GET /betaGroups/g1/relationships/builds": respond(`{"data":[{"id":"b2","type":"builds"},{"id":"b3","type":"builds"}]}`),
+		"GET /betaGroups/g2/relationships/builds": respond(`{"data":[{"id":"b2","type":"builds"}]}`),
+		"PATCH /builds/b2":                        expire,
+		"PATCH /builds/b4":                        expire,
+	})
+
+	return client, &requests
+}
+
+func TestPlanBuildRetention(t *testing.T) {
+	t.Parallel()
+
+	client, requests := newMockBuildRetentionServer(t)
+
+	plan, err := client.Builds.PlanBuildRetention(context.Background(), BuildRetentionPolicy{
+		AppID:                "a1",
+		KeepLatest:           1,
+		KeepAppStoreVersions: true,
+		OlderThan:            7 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []BuildExpiration{
+		{BuildID: "b4", Version: "4", PrereleaseVersion: "1.1", UploadedDate: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{BuildID: "b2", Version: "2", PrereleaseVersion: "1.0", UploadedDate: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), BetaGroups: []string{"Public", "QA"}},
+	}, plan.Expire)
+	assert.Equal(t, []RetainedBuild{
+		{BuildID: "b5", Version: "5", PrereleaseVersion: "1.1", Reason: "one of the latest builds of 1.1"},
+		{BuildID: "b3", Version: "3", PrereleaseVersion: "1.0", Reason: "one of the latest builds of 1.0"},
+		{BuildID: "b1", Version: "1", PrereleaseVersion: "1.0", Reason: "attached to an App Store version"},
+	}, plan.Keep)
+	assert.Equal(t, []BetaGroupBuildLoss{
+		{BetaGroupID: "g2", Name: "Public", Builds: []string{"b2"}, Remaining: 0},
+		{BetaGroupID: "g1", Name: "QA", Builds: []string{"b2"}, Remaining: 1},
+	}, plan.BetaGroups)
+	assert.Equal(t, "expire 1.1 (4) b4, uploaded 2020-05-01\n"+
+		"expire 1.0 (2) b2, uploaded 2020-03-01 [Public, QA]\n"+
+		"beta group Public loses 1 builds, 0 remaining\n"+
+		"beta group QA loses 1 builds, 1 remaining\n", plan.String())
+	assert.Empty(t, *requests)
+
+	plan, err = client.Builds.PlanBuildRetention(context.Background(), BuildRetentionPolicy{AppID: "a1"})
+	assert.NoError(t, err)
+	assert.Empty(t, plan.Expire)
+	assert.Empty(t, plan.BetaGroups)
+	assert.Len(t, plan.Keep, 5)
+
+	plan, err = client.Builds.PlanBuildRetention(context.Background(), BuildRetentionPolicy{AppID: "a1", OlderThan: 7 * 24 * time.Hour})
+	assert.NoError(t, err)
+	assert.Len(t, plan.Expire, 4)
+	assert.Len(t, plan.Keep, 1)
+	assert.Equal(t, "b5", plan.Keep[0].BuildID)
+	assert.Equal(t, "uploaded "+time.Now().Add(-time.Hour).UTC().Format("2006-01-02"), plan.Keep[0].Reason)
+}
+
+func TestExpireBuilds(t *testing.T) {
+	t.Parallel()
+
+	client, requests := newMockBuildRetentionServer(t)
+	policy := BuildRetentionPolicy{AppID: "a1", KeepLatest: 1, KeepAppStoreVersions: true, OlderThan: 7 * 24 * time.Hour}
+
+	report, err := client.Builds.ExpireBuilds(context.Background(), policy, true)
+	assert.NoError(t, err)
+	assert.Len(t, report.Results, 2)
+	assert.False(t, report.Results[0].Applied)
+	assert.Empty(t, *requests)
+
+	report, err = client.Builds.ExpireBuilds(context.Background(), policy, false)
+	assert.NoError(t, err)
+	assert.NoError(t, report.Err())
+	assert.Empty(t, report.Failed())
+	assert.Equal(t, []string{
+		`PATCH /builds/b4 {"data":{"attributes":{"expired":true},"id":"b4","type":"builds"}}` + "\n",
+		`PATCH /builds/b2 {"data":{"attributes":{"expired":true},"id":"b2","type":"builds"}}` + "\n",
+	}, *requests)
+	assert.Equal(t, "expire 1.1 (4) b4, uploaded 2020-05-01: ok\nexpire 1.0 (2) b2, uploaded 2020-03-01 [Public, QA]: ok\n", report.String())
+}
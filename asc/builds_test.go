@@ -22,6 +22,7 @@ package asc
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -119,6 +120,14 @@ func TestUpdateBuild(t *testing.T) {
 	testEndpointWithResponse(t, "{}", &BuildResponse{}, func(ctx context.Context, client *Client) (interface{}, *Response, error) {
 		return client.Builds.UpdateBuild(ctx, "10", Bool(true), nil, String("10"))
 	})
+
+	client, server := newRouteServer(t, map[string]http.HandlerFunc{
+		"PATCH /builds/10": respond(`{"data":{"id":"10","type":"builds"}}`),
+	})
+
+	_, _, err := client.Builds.UpdateBuild(context.Background(), "10", Bool(true), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PATCH /builds/10"}, server.requests())
 }
 
 func TestUpdateAppEncryptionDeclarationForBuild(t *testing.T) {
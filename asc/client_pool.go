@@ -0,0 +1,320 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownTeam happens when a ClientPool has no client for a team.
+type ErrUnknownTeam struct {
+	Team string
+}
+
+func (e ErrUnknownTeam) Error() string {
+	return fmt.Sprintf("no client for team %s", e.Team)
+}
+
+// ErrDuplicateTeam happens when a team is added to a ClientPool twice.
+type ErrDuplicateTeam struct {
+	Team string
+}
+
+func (e ErrDuplicateTeam) Error() string {
+	return fmt.Sprintf("team %s is already in the pool", e.Team)
+}
+
+// ErrBundleIDNotFound happens when no team of a ClientPool has an app with a bundle ID.
+type ErrBundleIDNotFound struct {
+	BundleID string
+}
+
+func (e ErrBundleIDNotFound) Error() string {
+	return fmt.Sprintf("no team has an app with bundle ID %s", e.BundleID)
+}
+
+// ErrTeam is an error that happened while making requests for a team of a ClientPool.
+type ErrTeam struct {
+	Team string
+	Err  error
+}
+
+func (e ErrTeam) Error() string {
+	return fmt.Sprintf("team %s: %v", e.Team, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrTeam) Unwrap() error {
+	return e.Err
+}
+
+// TeamResult is the result of a function run for one team by FanOut.
+type TeamResult[T any] struct {
+	Team  string
+	Value T
+	Err   error
+}
+
+// ClientPool holds a Client for each of several App Store Connect teams, each authenticated
+// with the API key of its team. Each client gets its own RateGovernor, since Apple enforces
+// rate limits per key, so that a busy team does not hold back requests for the others.
+//
+// A ClientPool is safe for concurrent use.
+type ClientPool struct {
+	// Reserve is the remaining hourly budget of a team at or below which the RateGovernor of its
+	// client starts pacing requests.
+	Reserve int
+	// MaxConcurrency is the number of teams that FanOut runs at once. Zero runs every team at once.
+	MaxConcurrency int
+
+	mu        sync.RWMutex
+	teams     []string
+	clients   map[string]*Client
+	governors map[string]*RateGovernor
+	bundleIDs map[string]string
+}
+
+// NewClientPool creates an empty ClientPool whose clients start pacing requests when the
+// remaining hourly budget of their team reaches reserve.
+func NewClientPool(reserve int) *ClientPool {
+	return &ClientPool{
+		Reserve:   reserve,
+		clients:   make(map[string]*Client),
+		governors: make(map[string]*RateGovernor),
+		bundleIDs: make(map[string]string),
+	}
+}
+
+// Add creates a Client for a team that authenticates with auth, and adds it to the pool.
+func (p *ClientPool) Add(team string, auth *AuthTransport) (*Client, error) {
+	client := NewClient(auth.Client())
+	if err := p.AddClient(team, client); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// AddProfile loads the credentials of a profile with LoadCredentials, and adds a Client
+// authenticated with them under the name of the profile.
+func (p *ClientPool) AddProfile(profile string, opts ...TokenOption) (*Client, error) {
+	auth, err := LoadAuthTransport(profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Add(profile, auth)
+}
+
+// AddClient adds an existing Client for a team. The client is given a new RateGovernor, unless it
+// already has one.
+func (p *ClientPool) AddClient(team string, client *Client) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.clients[team]; ok {
+		return ErrDuplicateTeam{Team: team}
+	}
+
+	if client.governor == nil {
+		client.SetRateGovernor(NewRateGovernor(p.Reserve))
+	}
+
+	p.teams = append(p.teams, team)
+	p.clients[team] = client
+	p.governors[team] = client.governor
+
+	return nil
+}
+
+// Teams returns the names of the teams in the pool, in the order they were added.
+func (p *ClientPool) Teams() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]string(nil), p.teams...)
+}
+
+// Team returns the Client of a team.
+func (p *ClientPool) Team(team string) (*Client, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	client, ok := p.clients[team]
+	if !ok {
+		return nil, ErrUnknownTeam{Team: team}
+	}
+
+	return client, nil
+}
+
+// Rates returns the most recently observed rate limit of every team.
+func (p *ClientPool) Rates() map[string]Rate {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	rates := make(map[string]Rate, len(p.governors))
+	for team, governor := range p.governors {
+		rates[team] = governor.Rate()
+	}
+
+	return rates
+}
+
+// ForBundleID returns the team and Client that own the app with a bundle ID. Teams are searched
+// with ListApps, and the team that is found is remembered for later calls. Teams that fail are
+// skipped, and an ErrTeam is returned only when no other team owns the app.
+func (p *ClientPool) ForBundleID(ctx context.Context, bundleID string) (string, *Client, error) {
+	p.mu.RLock()
+	team, ok := p.bundleIDs[bundleID]
+	p.mu.RUnlock()
+
+	if ok {
+		client, err := p.Team(team)
+
+		return team, client, err
+	}
+
+	results := FanOut(ctx, p, func(ctx context.Context, team string, client *Client) (bool, error) {
+		apps, _, err := client.Apps.ListApps(ctx, &ListAppsQuery{
+			FieldsApps:     []string{"bundleId"},
+			FilterBundleID: []string{bundleID},
+		})
+		if err != nil {
+			return false, err
+		}
+
+		for _, app := range apps.Data {
+			if app.Attributes != nil && app.Attributes.BundleID != nil && *app.Attributes.BundleID == bundleID {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+
+	var teamErr error
+
+	for _, result := range results {
+		if result.Value {
+			p.mu.Lock()
+			p.bundleIDs[bundleID] = result.Team
+			client := p.clients[result.Team]
+			p.mu.Unlock()
+
+			return result.Team, client, nil
+		}
+
+		if result.Err != nil && teamErr == nil {
+			teamErr = ErrTeam{Team: result.Team, Err: result.Err}
+		}
+	}
+
+	if teamErr != nil {
+		return "", nil, teamErr
+	}
+
+	return "", nil, ErrBundleIDNotFound{BundleID: bundleID}
+}
+
+// ListApps lists every app of every team, following every page, and returns them by team. If a
+// team fails, the apps of the other teams are returned along with an ErrTeam.
+func (p *ClientPool) ListApps(ctx context.Context, params *ListAppsQuery) (map[string][]App, error) {
+	results := FanOut(ctx, p, func(ctx context.Context, team string, client *Client) ([]App, error) {
+		apps, _, err := NewPager[App](client, func(ctx context.Context) (*AppsResponse, *Response, error) {
+			return client.Apps.ListApps(ctx, params)
+		}).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return apps.Data, nil
+	})
+
+	var err error
+
+	apps := make(map[string][]App, len(results))
+
+	for _, result := range results {
+		if result.Err != nil {
+			if err == nil {
+				err = ErrTeam{Team: result.Team, Err: result.Err}
+			}
+
+			continue
+		}
+
+		apps[result.Team] = result.Value
+	}
+
+	return apps, err
+}
+
+// FanOut runs fn for every team of a pool, running up to MaxConcurrency teams at once, and
+// returns their results in the order the teams were added. Requests made by fn are paced by the
+// RateGovernor of the team.
+func FanOut[T any](ctx context.Context, pool *ClientPool, fn func(ctx context.Context, team string, client *Client) (T, error)) []TeamResult[T] {
+	pool.mu.RLock()
+	teams := append([]string(nil), pool.teams...)
+	clients := make([]*Client, len(teams))
+
+	for i, team := range teams {
+		clients[i] = pool.clients[team]
+	}
+
+	concurrency := pool.MaxConcurrency
+	pool.mu.RUnlock()
+
+	if concurrency <= 0 || concurrency > len(teams) {
+		concurrency = len(teams)
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+
+	results := make([]TeamResult[T], len(teams))
+
+	for i := range teams {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := TeamResult[T]{Team: teams[i]}
+			if result.Err = ctx.Err(); result.Err == nil {
+				result.Value, result.Err = fn(ctx, teams[i], clients[i])
+			}
+
+			results[i] = result
+		}(i)
+	}
+
+	wg.Wait()
+
+	return results
+}
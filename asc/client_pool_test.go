@@ -0,0 +1,175 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockTeamClient returns a client for a team whose ListApps returns apps with the given
+// bundle IDs, and which has no app with the ID "missing".
+func newMockTeamClient(t *testing.T, remaining int, bundleIDs ...string) (*Client, *routeServer) {
+	t.Helper()
+
+	rated := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Rate-Limit", fmt.Sprintf("user-hour-lim:3600;user-hour-rem:%d;", remaining))
+			handler(w, r)
+		}
+	}
+
+	return newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /apps": rated(func(w http.ResponseWriter, r *http.Request) {
+			filter := r.URL.Query().Get("filter[bundleId]")
+			data := ""
+
+			for i, bundleID := range bundleIDs {
+				if filter != "" && filter != bundleID {
+					continue
+				}
+
+				if data != "" {
+					data += ","
+				}
+
+				data += fmt.Sprintf(`{"id":"%s-%d","type":"apps","attributes":{"bundleId":%q}}`, bundleID, i, bundleID)
+			}
+
+			fmt.Fprintf(w, `{"data":[%s]}`, data)
+		}),
+		"GET /apps/missing": rated(notFound),
+	})
+}
+
+func TestClientPool(t *testing.T) {
+	t.Parallel()
+
+	pool := NewClientPool(10)
+	acme, acmeServer := newMockTeamClient(t, 3000, "com.acme.app", "com.acme.other")
+	globex, _ := newMockTeamClient(t, 100, "com.globex.app")
+
+	assert.NoError(t, pool.AddClient("acme", acme))
+	assert.NoError(t, pool.AddClient("globex", globex))
+	assert.Equal(t, ErrDuplicateTeam{Team: "acme"}, pool.AddClient("acme", acme))
+	assert.Equal(t, []string{"acme", "globex"}, pool.Teams())
+
+	client, err := pool.Team("globex")
+	assert.NoError(t, err)
+	assert.Same(t, globex, client)
+
+	_, err = pool.Team("initech")
+	assert.Equal(t, ErrUnknownTeam{Team: "initech"}, err)
+
+	team, client, err := pool.ForBundleID(context.Background(), "com.globex.app")
+	assert.NoError(t, err)
+	assert.Equal(t, "globex", team)
+	assert.Same(t, globex, client)
+
+	before := len(acmeServer.requests())
+	team, _, err = pool.ForBundleID(context.Background(), "com.globex.app")
+	assert.NoError(t, err)
+	assert.Equal(t, "globex", team)
+	assert.Len(t, acmeServer.requests(), before)
+
+	_, _, err = pool.ForBundleID(context.Background(), "com.initech.app")
+	assert.Equal(t, ErrBundleIDNotFound{BundleID: "com.initech.app"}, err)
+
+	assert.Equal(t, map[string]Rate{
+		"acme":   {Limit: 3600, Remaining: 3000},
+		"globex": {Limit: 3600, Remaining: 100},
+	}, pool.Rates())
+}
+
+func TestClientPoolListApps(t *testing.T) {
+	t.Parallel()
+
+	pool := NewClientPool(0)
+	pool.MaxConcurrency = 1
+	acme, _ := newMockTeamClient(t, 3000, "com.acme.app", "com.acme.other")
+	globex, _ := newMockTeamClient(t, 3000, "com.globex.app")
+
+	assert.NoError(t, pool.AddClient("acme", acme))
+	assert.NoError(t, pool.AddClient("globex", globex))
+
+	apps, err := pool.ListApps(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, apps["acme"], 2)
+	assert.Len(t, apps["globex"], 1)
+
+	results := FanOut(context.Background(), pool, func(ctx context.Context, team string, client *Client) (string, error) {
+		if team == "globex" {
+			_, _, err := client.Apps.GetApp(ctx, "missing", nil)
+
+			return "", err
+		}
+
+		return team, nil
+	})
+	assert.Equal(t, "acme", results[0].Value)
+	assert.ErrorIs(t, results[1].Err, ErrNotFound)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	apps, err = pool.ListApps(ctx, nil)
+	assert.Empty(t, apps)
+
+	var teamErr ErrTeam
+
+	assert.True(t, errors.As(err, &teamErr))
+	assert.Equal(t, "acme", teamErr.Team)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestClientPoolForBundleIDSkipsFailedTeams(t *testing.T) {
+	t.Parallel()
+
+	revoked, _ := newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /apps": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errors":[{"status":"401","code":"NOT_AUTHORIZED"}]}`)
+		},
+	})
+
+	globex, _ := newMockTeamClient(t, 3000, "com.globex.app")
+
+	pool := NewClientPool(0)
+	assert.NoError(t, pool.AddClient("acme", revoked))
+	assert.NoError(t, pool.AddClient("globex", globex))
+
+	team, client, err := pool.ForBundleID(context.Background(), "com.globex.app")
+	assert.NoError(t, err)
+	assert.Equal(t, "globex", team)
+	assert.Same(t, globex, client)
+
+	_, _, err = pool.ForBundleID(context.Background(), "com.initech.app")
+
+	var teamErr ErrTeam
+
+	assert.True(t, errors.As(err, &teamErr))
+	assert.Equal(t, "acme", teamErr.Team)
+}
@@ -0,0 +1,130 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCredentialsFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api_key.json")
+	data, _ := json.Marshal(map[string]interface{}{
+		"key_id":                "KEY",
+		"issuer_id":             "ISSUER",
+		"key":                   base64.StdEncoding.EncodeToString(privPEMData),
+		"is_key_content_base64": true,
+		"duration":              600,
+		"in_house":              false,
+	})
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+
+	credentials, err := LoadCredentialsFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "KEY", credentials.KeyID)
+	assert.Equal(t, 600, credentials.Duration)
+
+	key, err := credentials.PrivateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, privPEMData, key)
+
+	auth, err := credentials.AuthTransport()
+	assert.NoError(t, err)
+
+	claims := tokenClaimsOf(t, auth)
+	assert.Equal(t, "ISSUER", claims["iss"])
+	assert.InDelta(t, 600, claims["exp"].(float64)-claims["iat"].(float64), 1)
+
+	_, err = LoadCredentialsFile(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCredentialsLoaderEnv(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "AuthKey_TEAM.p8"), privPEMData, 0o600))
+
+	env := map[string]string{
+		"ASC_KEY_ID":           "DEFAULT",
+		"ASC_ISSUER_ID":        "ISSUER",
+		"ASC_PRIVATE_KEY":      string(privPEMData),
+		"ASC_ACME_CORP_KEY_ID": "TEAM",
+	}
+	loader := CredentialsLoader{
+		Dir:     dir,
+		KeyDirs: []string{dir},
+		Getenv:  func(key string) string { return env[key] },
+	}
+
+	credentials, err := loader.Load("")
+	assert.NoError(t, err)
+	assert.Equal(t, &Credentials{KeyID: "DEFAULT", IssuerID: "ISSUER", Key: string(privPEMData)}, credentials)
+
+	credentials, err = loader.Load("acme-corp")
+	assert.NoError(t, err)
+	assert.Equal(t, &Credentials{KeyID: "TEAM", KeyFilepath: filepath.Join(dir, "AuthKey_TEAM.p8")}, credentials)
+
+	auth, err := credentials.AuthTransport()
+	assert.NoError(t, err)
+	assert.Equal(t, "user", tokenClaimsOf(t, auth)["sub"])
+
+	env["ASC_OTHER_KEY_ID"] = "NOKEY"
+	_, err = loader.Load("other")
+	assert.Equal(t, ErrMissingPrivateKey, err)
+}
+
+func TestCredentialsLoaderProfiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	loader := CredentialsLoader{
+		Dir:     dir,
+		KeyDirs: []string{},
+		Getenv:  func(key string) string { return "" },
+	}
+
+	_, err := loader.Load("")
+	assert.Equal(t, ErrCredentialsNotFound{Profile: DefaultProfile}, err)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "team.p8"), privPEMData, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "profiles.json"), []byte(`{
+		"default": {"key_id": "KEY", "issuer_id": "ISSUER", "key_filepath": "team.p8"}
+	}`), 0o600))
+
+	credentials, err := loader.Load(DefaultProfile)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "team.p8"), credentials.KeyFilepath)
+
+	_, err = credentials.AuthTransport()
+	assert.NoError(t, err)
+
+	_, err = loader.Load("missing")
+	assert.EqualError(t, err, "no credentials found for profile missing")
+}
@@ -81,7 +81,38 @@ to look a little more like this:
 		})
 	}
 
-The authenticated client created here will automatically regenerate the token if it expires.
+The authenticated client created here will automatically regenerate the token shortly before it
+expires. Tokens can be limited to specific requests with WithScope, and tokens for an individual
+API key, which has no issuer ID, are created with NewIndividualTokenConfig:
+
+	auth, err = asc.NewTokenConfig(keyID, issuerID, expiryDuration, privateKey,
+		asc.WithScope("GET /v1/apps"),
+		asc.WithRefreshMargin(2*time.Minute),
+	)
+
+Tokens come from a TokenSource, which NewAuthTransport accepts directly. A JWTTokenSource signs
+tokens with any crypto.Signer holding a P-256 key, so the private key can stay in a key
+management service instead of being read from disk:
+
+	source, err := asc.NewJWTTokenSource(keyID, issuerID, expiryDuration, kmsSigner)
+	client := asc.NewClient(asc.NewAuthTransport(source).Client())
+
+Rather than handling keys by hand, LoadAuthTransport finds the credentials of a named profile in
+ASC_ environment variables, in ~/.appstoreconnect/profiles.json or in a JSON API key file in the
+format used by fastlane, and looks for AuthKey_<KeyID>.p8 in the usual private_keys directories:
+
+	auth, err := asc.LoadAuthTransport("acme-corp")
+
+A ClientPool holds a client for each of several teams, each paced by its own RateGovernor. Clients
+are looked up by team name or by the bundle ID of one of their apps, and FanOut runs a function
+for every team at once:
+
+	pool := asc.NewClientPool(100)
+	pool.AddProfile("acme-corp")
+	pool.AddProfile("globex")
+	team, client, err := pool.ForBundleID(ctx, "com.globex.MyApp")
+	apps, err := pool.ListApps(ctx, nil)
+
 Also note that all App Store Connect APIs are scoped to the credentials of the pre-configured key,
 so you can't use this API to make queries against the entire App Store. For more information on
 creating the necessary credentials for the App Store Connect API, see the documentation at
@@ -93,8 +124,191 @@ Apple imposes a rate limit on all API clients. The returned Response.Rate value
 limit information from the most recent API call. If the API produces a rate limit error, it will be
 identifiable as an ErrorResponse with an error code of 429.
 
+To avoid exhausting the hourly budget, a RateGovernor can be attached to the client. It tracks the
+most recently observed Rate, spaces requests out once the remaining budget drops to a reserve, and
+holds requests after a 429 until the time given by the Retry-After header. A single RateGovernor can
+be shared by several clients using the same API key.
+
+	governor := asc.NewRateGovernor(100)
+	governor.OnRateChange = func(rate asc.Rate) {
+		log.Printf("%d of %d requests remaining", rate.Remaining, rate.Limit)
+	}
+	client.SetRateGovernor(governor)
+
+Requests that fail with a transient error, such as a 429, 500, 502, 503 or 504 response or a reset
+connection, are retried with exponential backoff according to the client's RetryPolicy. The default
+policy makes up to four attempts within a minute. Use SetRetryPolicy to customize it, or pass nil to
+disable retries altogether.
+
+	client.SetRetryPolicy(&asc.RetryPolicy{
+		MaxAttempts:    6,
+		MaxElapsedTime: 5 * time.Minute,
+		OnRetry: func(attempt asc.RetryAttempt) {
+			log.Printf("attempt %d failed: %v, retrying in %v", attempt.Attempt, attempt.Err, attempt.Delay)
+		},
+	})
+
 Learn more about rate limiting at https://developer.apple.com/documentation/appstoreconnectapi/identifying_rate_limits.
 
+Errors
+
+When the API responds with an error, methods return an *ErrorResponse holding every error
+Apple reported. It can be compared against sentinel errors such as ErrNotFound, ErrConflict
+or ErrEntityStateInvalid with errors.Is, and its hierarchical error codes can be matched by
+prefix with HasErrorCode:
+
+	_, err := client.Builds.UpdateAppEncryptionDeclarationForBuild(ctx, buildID, &declarationID)
+	if errors.Is(err, asc.ErrConflict) && asc.HasErrorCode(err, "ENTITY_ERROR.RELATIONSHIP") {
+		// the build already has an encryption declaration
+	}
+
+Observing Requests
+
+Every request made by the client can be observed through an Observer, which receives a
+structured Event when an attempt starts, when a response arrives, before a retry, and when a
+request finally fails. Events carry the method, path, status, duration, rate limit and any error
+IDs returned by Apple. Authorization headers and the bodies of asset uploads are always redacted.
+
+	client.SetObserver(asc.NewLogObserver(nil))
+
+Uploading Assets
+
+Screenshots, app previews, routing app coverage files and review attachments are uploaded by
+reserving the asset, uploading each part, committing it with a checksum and waiting for App
+Store Connect to process it. UploadScreenshot, UploadPreview, UploadRoutingAppCoverage and
+UploadAttachment perform every step, and delete the reservation if any of them fails:
+
+	screenshot, err := client.Apps.UploadScreenshot(ctx, screenshotSetID, stat.Name(), file, stat.Size())
+
+SyncScreenshots goes a step further, and makes every screenshot set of an App Store version match
+a directory tree with one directory per locale. Pass true for dryRun to only compute the plan:
+
+	plan, err := client.Apps.SyncScreenshots(ctx, versionID, "fastlane/screenshots", true)
+	fmt.Print(plan)
+
+Localized metadata can be synced the same way. SyncMetadata compares a Metadata document, loaded
+from JSON or YAML with LoadMetadata, with the App Store version and app info localizations, and
+only creates, updates or deletes what differs:
+
+	metadata, err := asc.LoadMetadata("metadata")
+	plan, err := client.Apps.SyncMetadata(ctx, metadata, asc.MetadataSyncOptions{
+		AppStoreVersionID: versionID,
+		AppInfoID:         appInfoID,
+	}, false)
+
+Releasing
+
+After a binary is uploaded, WaitForProcessing blocks until App Store Connect has processed it,
+polling with backoff and optionally reporting every change of its processing state:
+
+	build, err := client.Builds.WaitForProcessing(ctx, asc.WaitForProcessingOptions{
+		AppID:   appID,
+		Version: "42",
+		Timeout: time.Hour,
+	})
+
+A ReleaseWorkflow takes a build from processed to submitted. It finds or creates the App Store
+version, attaches the build, sets the review details and IDFA declaration, enables a phased
+release and submits the version for review, checking along the way that the build is processed
+and the version can still be edited. Every step is idempotent, and the State of an interrupted
+run can be saved from OnStep and given to a new workflow to resume it:
+
+	workflow := asc.NewReleaseWorkflow(client, asc.ReleaseSpec{
+		AppID:         appID,
+		Platform:      asc.PlatformIOS,
+		VersionString: "1.2.0",
+		BuildID:       buildID,
+		Submit:        true,
+	})
+	err := workflow.Run(ctx)
+
+AppStoreVersionState, BetaReviewState and ExternalBetaState know which transitions App Store
+Connect allows between them, and which states are terminal or wait for the developer. A
+StateWatcher polls a resource and yields every transition of its state until it is terminal:
+
+	watcher := client.Apps.WatchAppStoreVersion(versionID, time.Minute)
+	for watcher.Next(ctx) {
+		if watcher.State().IsActionable() {
+			notify(watcher.Transition())
+		}
+	}
+
+Once a version is out, a PhasedReleaseController can pause or complete its phased release based
+on the diagnostic signatures of the released build. PhasedReleaseThresholds covers
+common limits, and any PhasedReleasePolicy can be plugged in. Set DryRun to only log decisions:
+
+	controller := asc.NewPhasedReleaseController(client, versionID, asc.PhasedReleaseThresholds{
+		DiagnosticTypes:      []string{"HANGS"},
+		MaxSignatureWeight:   20,
+		CompleteAtPercentage: 50,
+	})
+	err := controller.Run(ctx, time.Hour)
+
+TestFlight
+
+External testers can be managed declaratively. A Roster, loaded from CSV or JSON with LoadRoster,
+lists the email address, name and beta groups of every tester, and SyncRoster creates the testers
+that are missing and adds or removes testers to and from groups in batches. Pass true for dryRun
+to only report the changes:
+
+	roster, err := asc.LoadRoster("testers.csv")
+	report, err := client.TestFlight.SyncRoster(ctx, roster, asc.RosterSyncOptions{
+		AppID: appID,
+		Prune: true,
+	}, false)
+	fmt.Print(report)
+
+DistributeBuild takes a processed build to testers. It provides its export compliance
+information, sets the "What to Test" notes, gives beta groups access to it, submits it to beta
+app review when a group has external testers and notifies testers once they can install it.
+Steps that are already done are skipped, so it can be run again once the build is approved:
+
+	report, err := client.TestFlight.DistributeBuild(ctx, asc.DistributionSpec{
+		BuildID:                 buildID,
+		BetaGroups:              []string{"QA", "Public Beta"},
+		WhatToTest:              map[string]string{"en-US": "Try the new onboarding flow"},
+		UsesNonExemptEncryption: asc.Bool(false),
+		Notify:                  true,
+	})
+
+PublicLinkReport counts the testers who joined every external beta group of an app through its
+public link, and ApplyPublicLinkPolicy sets a limit on links that have none, raises limits that
+are nearly used up and disables links that are full. Links can also be rotated, disabled or
+limited one group at a time:
+
+	actions, report, err := client.TestFlight.ApplyPublicLinkPolicy(ctx, appID, asc.PublicLinkPolicy{
+		EnforceLimit: 500,
+		RaiseAt:      0.9,
+		RaiseBy:      250,
+	})
+	fmt.Print(report)
+
+PruneStaleTesters frees external tester slots by removing testers that TesterPruneRules find
+stale, such as testers who cannot reach any build or whose builds have all expired. Members of
+internal groups are never pruned. The returned TesterPruneReport is an audit log of who was
+removed and why, which can be exported with WriteCSV or WriteJSON:
+
+	report, err := client.TestFlight.PruneStaleTesters(ctx, asc.TesterPruneRules{
+		AppID:         appID,
+		NoAccess:      true,
+		ExpiredBuilds: true,
+		ExpiredFor:    30 * 24 * time.Hour,
+	}, false)
+	err = report.WriteCSV(auditLog)
+
+ExpireBuilds cleans up builds ahead of the 90 days after which TestFlight expires them. A
+BuildRetentionPolicy expires builds older than a given age, except for the latest builds of each
+prerelease version and builds attached to an App Store version. Pass true for dryRun to preview
+the plan, which also lists the beta groups that lose access to builds:
+
+	report, err := client.Builds.ExpireBuilds(ctx, asc.BuildRetentionPolicy{
+		AppID:                appID,
+		KeepLatest:           3,
+		KeepAppStoreVersions: true,
+		OlderThan:            30 * 24 * time.Hour,
+	}, true)
+	fmt.Print(report.Plan)
+
 Pagination
 
 All requests for resource collections (apps, builds, beta groups, etc.) support pagination.
@@ -104,29 +318,29 @@ with the Cursor() method, and that can be passed to a query param using its Curs
 You can also find more information about the per-page limit and total count of resources in
 the response's Meta field of type PagingInformation.
 
+Rather than following cursors by hand, a Pager can walk every page of a collection lazily.
+The Pager is given the item type explicitly and infers the response type from the closure
+that fetches the first page:
+
 	auth, _ = asc.NewTokenConfig(keyID, issuerID, expiryDuration, privateKey)
 	client := asc.NewClient(auth.Client())
 
-	opt := &asc.ListAppsQuery{
-		FilterBundleID: []string{"com.sky.MyApp"},
-	}
+	pager := asc.NewPager[asc.App](client, func(ctx context.Context) (*asc.AppsResponse, *asc.Response, error) {
+		return client.Apps.ListApps(ctx, &asc.ListAppsQuery{
+			FilterBundleID: []string{"com.sky.MyApp"},
+		})
+	})
 
-	var allApps []asc.App
-	for {
-		apps, _, err := apps, _, err := client.Apps.ListApps(opt)
-		if err != nil {
-			return err
-		}
-		allApps = append(allApps, apps.Data...)
-		if apps.Links.Next == nil {
-			break
-		}
-		cursor := apps.Links.Next.Cursor()
-		if cursor == "" {
-			break
-		}
-		opt.Cursor = cursor
+	for pager.Next(ctx) {
+		app := pager.Item()
+		// ...
 	}
+	if err := pager.Err(); err != nil {
+		return err
+	}
+
+Set MaxItems on the Pager to stop after a given number of items, or call All to collect every
+page into a single response with the Data and Included of each page merged together.
 
 */
 package asc
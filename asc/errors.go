@@ -0,0 +1,198 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sentinel errors that an ErrorResponse can be compared against with errors.Is.
+var (
+	// ErrUnauthorized matches responses with a 401 status, usually caused by an invalid or expired token.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden matches responses with a 403 status, caused by a key lacking the required role.
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound matches responses with a 404 status.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict matches responses with a 409 status, such as when a relationship already exists
+	// or a resource is in a state that does not allow the change.
+	ErrConflict = errors.New("conflict")
+	// ErrRateLimited matches responses with a 429 status.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrEntityStateInvalid matches responses containing an error with the ENTITY_STATE_INVALID
+	// code, which App Store Connect returns when a resource is in the wrong state for the request.
+	ErrEntityStateInvalid = errors.New("entity state invalid")
+)
+
+const errorCodeEntityStateInvalid = "ENTITY_STATE_INVALID"
+
+var sentinelStatusCodes = map[error]int{
+	ErrUnauthorized: http.StatusUnauthorized,
+	ErrForbidden:    http.StatusForbidden,
+	ErrNotFound:     http.StatusNotFound,
+	ErrConflict:     http.StatusConflict,
+	ErrRateLimited:  http.StatusTooManyRequests,
+}
+
+// Is reports whether the ErrorResponse matches one of the sentinel errors in this package,
+// so that it can be used with errors.Is:
+//
+//	if errors.Is(err, asc.ErrNotFound) {
+//		// ...
+//	}
+func (e ErrorResponse) Is(target error) bool {
+	if target == ErrEntityStateInvalid { // nolint: errorlint, goerr113
+		return e.hasCodeSegment(errorCodeEntityStateInvalid)
+	}
+
+	status, ok := sentinelStatusCodes[target]
+	if !ok {
+		return false
+	}
+
+	if e.Response != nil && e.Response.StatusCode == status {
+		return true
+	}
+
+	for _, err := range e.Errors {
+		if err.StatusCode() == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasCode reports whether any error in the response, including associated errors, matches the
+// given hierarchical code. See ErrorResponseError.HasCode.
+func (e ErrorResponse) HasCode(code string) bool {
+	for _, err := range e.AllErrors() {
+		if err.HasCode(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllErrors returns every error in the response, each followed by its associated errors,
+// recursively. Associated errors are ordered by route.
+func (e ErrorResponse) AllErrors() []ErrorResponseError {
+	var all []ErrorResponseError
+
+	var walk func(errs []ErrorResponseError)
+
+	walk = func(errs []ErrorResponseError) {
+		for _, err := range errs {
+			all = append(all, err)
+
+			if err.Meta == nil {
+				continue
+			}
+
+			routes := make([]string, 0, len(err.Meta.AssociatedErrors))
+			for route := range err.Meta.AssociatedErrors {
+				routes = append(routes, route)
+			}
+
+			sort.Strings(routes)
+
+			for _, route := range routes {
+				walk(err.Meta.AssociatedErrors[route])
+			}
+		}
+	}
+
+	walk(e.Errors)
+
+	return all
+}
+
+// ErrorsByRoute flattens the errors in the response and their associated errors, recursively,
+// into a map keyed by route. Top-level errors are stored under the empty route.
+func (e ErrorResponse) ErrorsByRoute() map[string][]ErrorResponseError {
+	routes := make(map[string][]ErrorResponseError)
+	flattenErrors("", e.Errors, routes)
+
+	return routes
+}
+
+func flattenErrors(route string, errs []ErrorResponseError, routes map[string][]ErrorResponseError) {
+	for _, err := range errs {
+		routes[route] = append(routes[route], err)
+
+		if err.Meta == nil {
+			continue
+		}
+
+		for associatedRoute, associated := range err.Meta.AssociatedErrors {
+			flattenErrors(associatedRoute, associated, routes)
+		}
+	}
+}
+
+func (e ErrorResponse) hasCodeSegment(segment string) bool {
+	for _, err := range e.AllErrors() {
+		for _, s := range strings.Split(err.Code, ".") {
+			if s == segment {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasCode reports whether the error's hierarchical code matches the given code. A code matches
+// itself and every more specific code below it, so "ENTITY_ERROR" and "ENTITY_ERROR.ATTRIBUTE"
+// both match "ENTITY_ERROR.ATTRIBUTE.INVALID", but "ENTITY_ERROR.ATTR" does not.
+func (e ErrorResponseError) HasCode(code string) bool {
+	if code == "" {
+		return false
+	}
+
+	return e.Code == code || strings.HasPrefix(e.Code, code+".")
+}
+
+// StatusCode returns the Status of the error as an integer, or zero if it cannot be parsed.
+func (e ErrorResponseError) StatusCode() int {
+	status, err := strconv.Atoi(e.Status)
+	if err != nil {
+		return 0
+	}
+
+	return status
+}
+
+// HasErrorCode reports whether err is an ErrorResponse containing an error that matches the
+// given hierarchical code.
+func HasErrorCode(err error, code string) bool {
+	var respErr *ErrorResponse
+	if !errors.As(err, &respErr) {
+		return false
+	}
+
+	return respErr.HasCode(code)
+}
@@ -0,0 +1,133 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mockConflictPayload = `{
+	"errors": [
+		{
+			"id": "1",
+			"status": "409",
+			"code": "ENTITY_ERROR.RELATIONSHIP.INVALID",
+			"title": "The relationship is invalid",
+			"detail": "The build is already attached",
+			"meta": {
+				"associatedErrors": {
+					"/v1/builds/10": [
+						{
+							"status": "409",
+							"code": "STATE_ERROR.ENTITY_STATE_INVALID",
+							"meta": {
+								"associatedErrors": {
+									"/v1/apps/20": [
+										{"status": "409", "code": "ENTITY_ERROR.ATTRIBUTE.INVALID"}
+									]
+								}
+							}
+						}
+					]
+				}
+			}
+		}
+	]
+}`
+
+func TestErrorResponseIs(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer(mockConflictPayload, http.StatusConflict, false)
+	defer server.Close()
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, ErrEntityStateInvalid)
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", err), ErrConflict)
+	assert.False(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, errors.New("other")))
+}
+
+func TestErrorResponseIsFromErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	err := ErrorResponse{Errors: []ErrorResponseError{{Status: "429"}, {Status: "bad"}}}
+
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.False(t, errors.Is(err, ErrUnauthorized))
+	assert.False(t, errors.Is(err, ErrForbidden))
+	assert.False(t, errors.Is(err, ErrEntityStateInvalid))
+}
+
+func TestErrorResponseHasCode(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer(mockConflictPayload, http.StatusConflict, false)
+	defer server.Close()
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+
+	assert.True(t, HasErrorCode(err, "ENTITY_ERROR"))
+	assert.True(t, HasErrorCode(err, "ENTITY_ERROR.RELATIONSHIP.INVALID"))
+	assert.True(t, HasErrorCode(err, "ENTITY_ERROR.ATTRIBUTE"))
+	assert.True(t, HasErrorCode(err, "STATE_ERROR"))
+	assert.False(t, HasErrorCode(err, "ENTITY_ERROR.REL"))
+	assert.False(t, HasErrorCode(err, ""))
+	assert.False(t, HasErrorCode(errors.New("other"), "ENTITY_ERROR"))
+}
+
+func TestErrorResponseErrorsByRoute(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer(mockConflictPayload, http.StatusConflict, false)
+	defer server.Close()
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+
+	var respErr *ErrorResponse
+
+	assert.True(t, errors.As(err, &respErr))
+
+	routes := respErr.ErrorsByRoute()
+	assert.Len(t, routes, 3)
+	assert.Equal(t, "ENTITY_ERROR.RELATIONSHIP.INVALID", routes[""][0].Code)
+	assert.Equal(t, "STATE_ERROR.ENTITY_STATE_INVALID", routes["/v1/builds/10"][0].Code)
+	assert.Equal(t, "ENTITY_ERROR.ATTRIBUTE.INVALID", routes["/v1/apps/20"][0].Code)
+
+	all := respErr.AllErrors()
+	assert.Len(t, all, 3)
+	assert.Equal(t, "ENTITY_ERROR.ATTRIBUTE.INVALID", all[2].Code)
+}
+
+func TestErrorResponseErrorStatusCode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 404, ErrorResponseError{Status: "404"}.StatusCode())
+	assert.Equal(t, 0, ErrorResponseError{Status: ""}.StatusCode())
+}
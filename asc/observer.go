@@ -0,0 +1,307 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// redactedHeaders are request headers whose values are never exposed to observers or debug output.
+var redactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// EventKind identifies the stage of a request an Event describes.
+type EventKind string
+
+const (
+	// EventRequest is emitted before each attempt of a request is sent.
+	EventRequest EventKind = "request"
+	// EventResponse is emitted when an attempt produces a response, regardless of its status code.
+	EventResponse EventKind = "response"
+	// EventRetry is emitted when a failed attempt is about to be retried.
+	EventRetry EventKind = "retry"
+	// EventError is emitted once when a request finally fails, either with a transport error
+	// or an ErrorResponse.
+	EventError EventKind = "error"
+)
+
+// Event is a structured description of the activity of a Client. Authorization headers are
+// redacted, and bodies of asset uploads are replaced with a placeholder.
+type Event struct {
+	Kind EventKind
+	// Method is the HTTP method of the request.
+	Method string
+	// URL is the full URL of the request.
+	URL string
+	// Path is the path component of the request URL.
+	Path string
+	// Attempt is the number of the attempt the event relates to, starting at 1.
+	Attempt int
+	// StatusCode is the status code of the response, if there was one.
+	StatusCode int
+	// Duration is the time spent on the attempt for EventResponse, and on the whole
+	// request including retries for EventError.
+	Duration time.Duration
+	// Delay is the time the client waits before the next attempt. Only set for EventRetry.
+	Delay time.Duration
+	// Rate is the rate limit information from the response, if there was one.
+	Rate Rate
+	// ErrorIDs are the IDs of the errors returned by App Store Connect, which should be
+	// included when reporting issues to Apple. Only set for EventError.
+	ErrorIDs []string
+	// Err is the error that caused a retry or failure.
+	Err error
+	// Header holds the request headers for EventRequest, and the response headers for EventResponse.
+	Header http.Header
+	// Body holds the request body for EventRequest.
+	Body []byte
+}
+
+// Observer receives structured events about the requests made by a Client.
+// Observe is called synchronously, so implementations should return quickly.
+type Observer interface {
+	Observe(Event)
+}
+
+// ObserverFunc is an adapter to allow the use of ordinary functions as an Observer.
+type ObserverFunc func(Event)
+
+// Observe calls f(e).
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// SetObserver sets the Observer that receives events about the requests made by this client.
+// Passing nil disables observation.
+func (c *Client) SetObserver(o Observer) {
+	c.observer = o
+}
+
+// NewLogObserver returns an Observer that writes one line per event to the given logger.
+// If logger is nil, the standard logger is used.
+func NewLogObserver(logger *log.Logger) Observer {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return ObserverFunc(func(e Event) {
+		logger.Print(e.String())
+	})
+}
+
+// String formats the Event as a single line of key=value pairs.
+func (e Event) String() string {
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("asc %s method=%s path=%s attempt=%d", e.Kind, e.Method, e.Path, e.Attempt))
+
+	if e.StatusCode != 0 {
+		str.WriteString(fmt.Sprintf(" status=%d", e.StatusCode))
+	}
+
+	if e.Duration != 0 {
+		str.WriteString(fmt.Sprintf(" duration=%v", e.Duration))
+	}
+
+	if e.Delay != 0 {
+		str.WriteString(fmt.Sprintf(" delay=%v", e.Delay))
+	}
+
+	if e.Rate.Limit != 0 {
+		str.WriteString(fmt.Sprintf(" rate=%d/%d", e.Rate.Remaining, e.Rate.Limit))
+	}
+
+	if len(e.ErrorIDs) > 0 {
+		str.WriteString(fmt.Sprintf(" error_ids=%s", strings.Join(e.ErrorIDs, ",")))
+	}
+
+	if e.Err != nil {
+		str.WriteString(fmt.Sprintf(" err=%q", e.Err.Error()))
+	}
+
+	return str.String()
+}
+
+func (c *Client) observing() bool {
+	return c.observer != nil || c.httpDebug
+}
+
+func (c *Client) emit(e Event) {
+	if c.observer != nil {
+		c.observer.Observe(e)
+	}
+}
+
+func newRequestEvent(kind EventKind, req *http.Request, attempt int) Event {
+	return Event{
+		Kind:    kind,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Path:    req.URL.Path,
+		Attempt: attempt,
+	}
+}
+
+// observeRequest emits an EventRequest for the given attempt and prints the request if HTTP
+// debugging is enabled.
+func (c *Client) observeRequest(req *http.Request, attempt int) {
+	if !c.observing() {
+		return
+	}
+
+	header := redactHeader(req.Header)
+	body := redactBody(req)
+
+	if c.httpDebug {
+		if dump, err := dumpRequest(req, header, body); err == nil {
+			fmt.Printf("DEBUG request uri=%s\n%s\n", req.URL, dump) // nolint: forbidigo
+		}
+	}
+
+	e := newRequestEvent(EventRequest, req, attempt)
+	e.Header = header
+	e.Body = body
+	c.emit(e)
+}
+
+// observeResponse emits an EventResponse for the given attempt and prints the response if HTTP
+// debugging is enabled.
+func (c *Client) observeResponse(req *http.Request, resp *http.Response, attempt int, duration time.Duration) {
+	if !c.observing() {
+		return
+	}
+
+	if c.httpDebug {
+		if dump, err := httputil.DumpResponse(resp, true); err == nil {
+			fmt.Printf("DEBUG response uri=%s\n%s\n", req.URL, dump) // nolint: forbidigo
+		}
+	}
+
+	e := newRequestEvent(EventResponse, req, attempt)
+	e.StatusCode = resp.StatusCode
+	e.Duration = duration
+	e.Rate = parseRate(resp)
+	e.Header = resp.Header.Clone()
+	c.emit(e)
+}
+
+// observeRetry emits an EventRetry for the given attempt.
+func (c *Client) observeRetry(req *http.Request, attempt RetryAttempt) {
+	if !c.observing() {
+		return
+	}
+
+	if c.httpDebug {
+		fmt.Printf("DEBUG error %v, retry in %v\n", attempt.Err, attempt.Delay) // nolint: forbidigo
+	}
+
+	e := newRequestEvent(EventRetry, req, attempt.Attempt)
+	e.StatusCode = attempt.StatusCode
+	e.Delay = attempt.Delay
+	e.Err = attempt.Err
+	c.emit(e)
+}
+
+// observeError emits an EventError for a request that finally failed.
+func (c *Client) observeError(req *http.Request, resp *Response, attempt int, duration time.Duration, err error) {
+	if err == nil || c.observer == nil {
+		return
+	}
+
+	e := newRequestEvent(EventError, req, attempt)
+	e.Duration = duration
+	e.Err = err
+
+	if resp != nil && resp.Response != nil {
+		e.StatusCode = resp.StatusCode
+		e.Rate = resp.Rate
+	}
+
+	var respErr *ErrorResponse
+	if errors.As(err, &respErr) {
+		for _, apiErr := range respErr.Errors {
+			if apiErr.ID != nil {
+				e.ErrorIDs = append(e.ErrorIDs, *apiErr.ID)
+			}
+		}
+	}
+
+	c.emit(e)
+}
+
+// redactHeader returns a copy of the header with credentials replaced by a placeholder.
+func redactHeader(h http.Header) http.Header {
+	clone := h.Clone()
+	if clone == nil {
+		clone = http.Header{}
+	}
+
+	for _, key := range redactedHeaders {
+		if clone.Get(key) != "" {
+			clone.Set(key, redacted)
+		}
+	}
+
+	return clone
+}
+
+// redactBody returns a copy of the request body. Bodies that are not JSON, such as the chunks
+// of an asset upload, are replaced with a placeholder describing their size.
+func redactBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil {
+		return nil
+	}
+
+	if !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		return []byte(fmt.Sprintf("%s (%d bytes)", redacted, req.ContentLength))
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+
+	defer closeDesc(body)
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// dumpRequest formats a request like httputil.DumpRequest, with the given redacted header and body.
+func dumpRequest(req *http.Request, header http.Header, body []byte) ([]byte, error) {
+	clone := req.Clone(req.Context())
+	clone.Header = header
+	clone.Body = io.NopCloser(strings.NewReader(string(body)))
+	clone.ContentLength = int64(len(body))
+
+	return httputil.DumpRequest(clone, true)
+}
@@ -0,0 +1,186 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockObserver struct {
+	events []Event
+}
+
+func (o *mockObserver) Observe(e Event) {
+	o.events = append(o.events, e)
+}
+
+func (o *mockObserver) kinds() []EventKind {
+	kinds := make([]EventKind, len(o.events))
+	for i, e := range o.events {
+		kinds[i] = e.Kind
+	}
+
+	return kinds
+}
+
+func TestSetObserver(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(nil)
+	observer := &mockObserver{}
+	client.SetObserver(observer)
+	assert.Equal(t, observer, client.observer)
+	client.SetObserver(nil)
+	assert.Nil(t, client.observer)
+}
+
+func TestObserverReceivesRequestAndResponse(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer(marshaledMockPayload, http.StatusOK, true)
+	defer server.Close()
+
+	observer := &mockObserver{}
+	client.SetObserver(observer)
+
+	_, err := client.post(context.Background(), "test", newRequestBody(mockBody{"TEST"}), nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []EventKind{EventRequest, EventResponse}, observer.kinds())
+
+	request := observer.events[0]
+	assert.Equal(t, "POST", request.Method)
+	assert.Equal(t, "/test", request.Path)
+	assert.Equal(t, 1, request.Attempt)
+	assert.JSONEq(t, `{"data":{"Field":"TEST"}}`, string(request.Body))
+
+	response := observer.events[1]
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, Rate{Limit: 2500, Remaining: 10}, response.Rate)
+	assert.NotEmpty(t, response.String())
+}
+
+func TestObserverReceivesRetryAndError(t *testing.T) {
+	t.Parallel()
+
+	client, server, _ := newFlakyServer(5, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond})
+
+	observer := &mockObserver{}
+	client.SetObserver(observer)
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+	assert.Error(t, err)
+
+	assert.Equal(t, []EventKind{EventRequest, EventResponse, EventRetry, EventRequest, EventResponse, EventError}, observer.kinds())
+
+	last := observer.events[len(observer.events)-1]
+	assert.Equal(t, 2, last.Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, last.StatusCode)
+	assert.Error(t, last.Err)
+}
+
+func TestObserverErrorIDs(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer(`{"errors":[{"id":"abc-123","code":"NOT_FOUND","status":"404"},{"code":"NOT_FOUND"}]}`, http.StatusNotFound, false)
+	defer server.Close()
+
+	observer := &mockObserver{}
+	client.SetObserver(observer)
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+	assert.Error(t, err)
+
+	last := observer.events[len(observer.events)-1]
+	assert.Equal(t, EventError, last.Kind)
+	assert.Equal(t, []string{"abc-123"}, last.ErrorIDs)
+	assert.Contains(t, last.String(), "error_ids=abc-123")
+}
+
+func TestLogObserver(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	observer := NewLogObserver(log.New(buf, "", 0))
+	observer.Observe(Event{
+		Kind:       EventRetry,
+		Method:     "GET",
+		Path:       "/v1/apps",
+		Attempt:    1,
+		StatusCode: http.StatusBadGateway,
+		Duration:   time.Second,
+		Delay:      time.Second,
+		Rate:       Rate{Limit: 3600, Remaining: 3599},
+		Err:        errors.New("bad gateway"),
+	})
+
+	assert.Equal(t, `asc retry method=GET path=/v1/apps attempt=1 status=502 duration=1s delay=1s rate=3599/3600 err="bad gateway"`+"\n", buf.String())
+	assert.NotNil(t, NewLogObserver(nil))
+}
+
+func TestRedactHeader(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	header.Set("Accept", "application/json")
+
+	got := redactHeader(header)
+	assert.Equal(t, redacted, got.Get("Authorization"))
+	assert.Equal(t, "application/json", got.Get("Accept"))
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"), "original header is left untouched")
+	assert.NotNil(t, redactHeader(nil))
+}
+
+func TestRedactBody(t *testing.T) {
+	t.Parallel()
+
+	req, _ := http.NewRequestWithContext(context.Background(), "PUT", "test", strings.NewReader("binary asset"))
+	req.Header.Set("Content-Type", "image/png")
+	assert.Equal(t, "REDACTED (12 bytes)", string(redactBody(req)))
+
+	req, _ = http.NewRequestWithContext(context.Background(), "GET", "test", nil)
+	assert.Nil(t, redactBody(req))
+}
+
+func TestDumpRequestRedacted(t *testing.T) {
+	t.Parallel()
+
+	req, _ := http.NewRequestWithContext(context.Background(), "PUT", "https://example.com/upload", strings.NewReader("binary asset"))
+	req.Header.Set("Authorization", "Bearer secret")
+
+	dump, err := dumpRequest(req, redactHeader(req.Header), redactBody(req))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(dump), "secret")
+	assert.NotContains(t, string(dump), "binary asset")
+}
@@ -0,0 +1,262 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ErrInvalidPage happens when a Pager is constructed with a response type that does not
+// have the shape of a paginated collection response, such as AppsResponse.
+type ErrInvalidPage struct {
+	Type string
+}
+
+func (e ErrInvalidPage) Error() string {
+	return fmt.Sprintf("type %s is not a paginated collection response", e.Type)
+}
+
+// PageFunc fetches the first page of a paginated collection. It is typically a closure
+// around one of the List methods on a service, such as AppsService.ListApps.
+type PageFunc[R any] func(ctx context.Context) (*R, *Response, error)
+
+// Pager lazily walks every page of a paginated collection, yielding each resource of type T
+// in order. R is the collection response type (for example, AppsResponse for App), and must
+// have the Data and Links fields shared by every paginated response in this package.
+//
+// Pages after the first are fetched by following PagedDocumentLinks.Next, so any filters,
+// fields, includes and limits passed to the first request are preserved.
+type Pager[T any, R any] struct {
+	// MaxItems caps the total number of items the Pager will yield. Zero means no cap.
+	MaxItems int
+
+	client *Client
+	first  PageFunc[R]
+
+	page     *R
+	items    []T
+	index    int
+	yielded  int
+	next     *Reference
+	included reflect.Value
+	resp     *Response
+	started  bool
+	done     bool
+	err      error
+}
+
+// NewPager creates a new Pager that starts with the page returned by first. The item type
+// must be given explicitly, while the response type is inferred from first:
+//
+//	pager := asc.NewPager[asc.App](client, func(ctx context.Context) (*asc.AppsResponse, *asc.Response, error) {
+//		return client.Apps.ListApps(ctx, &asc.ListAppsQuery{Limit: 200})
+//	})
+func NewPager[T any, R any](client *Client, first PageFunc[R]) *Pager[T, R] {
+	return &Pager[T, R]{
+		client: client,
+		first:  first,
+	}
+}
+
+// Next advances the Pager to the next item, fetching the next page if the current one has
+// been exhausted. It returns false when there are no more items, the MaxItems cap has been
+// reached, the context is done, or an error occurred. Check Err after Next returns false.
+func (p *Pager[T, R]) Next(ctx context.Context) bool {
+	if p.err != nil || p.capped() {
+		return false
+	}
+
+	for p.index >= len(p.items) {
+		if p.done {
+			return false
+		}
+
+		if !p.fetch(ctx) {
+			return false
+		}
+	}
+
+	p.index++
+	p.yielded++
+
+	return true
+}
+
+// Item returns the current item. It is only valid after a call to Next returns true.
+func (p *Pager[T, R]) Item() T {
+	return p.items[p.index-1]
+}
+
+// Page returns the most recently fetched page, or nil if no page has been fetched yet.
+func (p *Pager[T, R]) Page() *R {
+	return p.page
+}
+
+// Response returns the Response of the most recently fetched page, which contains
+// the latest rate limit information.
+func (p *Pager[T, R]) Response() *Response {
+	return p.resp
+}
+
+// Paging returns the paging information of the most recently fetched page, if the API
+// provided any.
+func (p *Pager[T, R]) Paging() *PagingInformation {
+	if p.page == nil {
+		return nil
+	}
+
+	meta := reflect.ValueOf(p.page).Elem().FieldByName("Meta")
+	if !meta.IsValid() {
+		return nil
+	}
+
+	if info, ok := meta.Interface().(*PagingInformation); ok {
+		return info
+	}
+
+	return nil
+}
+
+// Err returns the first error encountered while fetching pages, including context cancellation.
+func (p *Pager[T, R]) Err() error {
+	return p.err
+}
+
+// All walks every remaining page and returns a single response whose Data contains every
+// item yielded, up to MaxItems, and whose Included contains the included resources of every
+// page that was fetched. Links and Meta are taken from the last page fetched.
+func (p *Pager[T, R]) All(ctx context.Context) (*R, *Response, error) {
+	var data []T
+
+	for p.Next(ctx) {
+		data = append(data, p.Item())
+	}
+
+	if p.err != nil {
+		return nil, p.resp, p.err
+	}
+
+	merged := new(R)
+	if p.page != nil {
+		*merged = *p.page
+	}
+
+	v := reflect.ValueOf(merged).Elem()
+	v.FieldByName("Data").Set(reflect.ValueOf(data))
+
+	if p.included.IsValid() {
+		v.FieldByName("Included").Set(p.included)
+	}
+
+	return merged, p.resp, nil
+}
+
+func (p *Pager[T, R]) capped() bool {
+	return p.MaxItems > 0 && p.yielded >= p.MaxItems
+}
+
+func (p *Pager[T, R]) fetch(ctx context.Context) bool {
+	if err := ctx.Err(); err != nil {
+		p.err = err
+
+		return false
+	}
+
+	var (
+		page *R
+		resp *Response
+		err  error
+	)
+
+	if !p.started {
+		p.started = true
+		page, resp, err = p.first(ctx)
+	} else {
+		page = new(R)
+		resp, err = p.client.FollowReference(ctx, p.next, page)
+	}
+
+	p.resp = resp
+
+	if err != nil {
+		p.err = err
+
+		return false
+	}
+
+	if page == nil {
+		page = new(R)
+	}
+
+	items, next, err := p.unpack(page)
+	if err != nil {
+		p.err = err
+
+		return false
+	}
+
+	p.page = page
+	p.items = items
+	p.index = 0
+	p.next = next
+	p.done = next == nil || next.Cursor() == ""
+
+	return true
+}
+
+// unpack extracts the items, next page reference and included resources from a page.
+func (p *Pager[T, R]) unpack(page *R) ([]T, *Reference, error) {
+	v := reflect.ValueOf(page).Elem()
+	if v.Kind() != reflect.Struct {
+		return nil, nil, ErrInvalidPage{Type: v.Type().String()}
+	}
+
+	items, ok := fieldValue(v, "Data").([]T)
+	if !ok {
+		return nil, nil, ErrInvalidPage{Type: v.Type().String()}
+	}
+
+	links, ok := fieldValue(v, "Links").(PagedDocumentLinks)
+	if !ok {
+		return nil, nil, ErrInvalidPage{Type: v.Type().String()}
+	}
+
+	if included := v.FieldByName("Included"); included.IsValid() && included.Kind() == reflect.Slice {
+		if !p.included.IsValid() {
+			p.included = reflect.MakeSlice(included.Type(), 0, included.Len())
+		}
+
+		p.included = reflect.AppendSlice(p.included, included)
+	}
+
+	return items, links.Next, nil
+}
+
+func fieldValue(v reflect.Value, name string) interface{} {
+	field := v.FieldByName(name)
+	if !field.IsValid() {
+		return nil
+	}
+
+	return field.Interface()
+}
@@ -0,0 +1,177 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newPagedServer serves the given pages of apps, linking each page to the next one.
+func newPagedServer(t *testing.T, pages []string) *Client {
+	t.Helper()
+
+	client, _ := newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /apps": func(w http.ResponseWriter, r *http.Request) {
+			index := 0
+			if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+				fmt.Sscanf(cursor, "%d", &index) // nolint: errcheck
+			}
+
+			self := "http://" + r.Host + "/apps"
+
+			next := ""
+			if index+1 < len(pages) {
+				next = fmt.Sprintf(`,"next":"%s?cursor=%d"`, self, index+1)
+			}
+
+			fmt.Fprintf(w, `{"data":%s,"included":[{"type":"builds"}],"links":{"self":%q%s},"meta":{"paging":{"limit":2,"total":5}}}`, pages[index], self, next)
+		},
+	})
+
+	return client
+}
+
+func newAppsPager(client *Client) *Pager[App, AppsResponse] {
+	return NewPager[App](client, func(ctx context.Context) (*AppsResponse, *Response, error) {
+		return client.Apps.ListApps(ctx, &ListAppsQuery{Limit: 2})
+	})
+}
+
+type mockInvalidPage struct {
+	Data []App `json:"data"`
+}
+
+var mockAppPages = []string{
+	`[{"id":"1"},{"id":"2"}]`,
+	`[{"id":"3"},{"id":"4"}]`,
+	`[{"id":"5"}]`,
+}
+
+func TestPagerWalksAllPages(t *testing.T) {
+	t.Parallel()
+
+	client := newPagedServer(t, mockAppPages)
+
+	pager := newAppsPager(client)
+
+	var ids []string
+	for pager.Next(context.Background()) {
+		ids = append(ids, pager.Item().ID)
+	}
+
+	assert.NoError(t, pager.Err())
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, ids)
+	assert.NotNil(t, pager.Response())
+	assert.Equal(t, 5, pager.Paging().Paging.Total)
+	assert.False(t, pager.Next(context.Background()))
+}
+
+func TestPagerAllMergesIncluded(t *testing.T) {
+	t.Parallel()
+
+	client := newPagedServer(t, mockAppPages)
+
+	all, resp, err := newAppsPager(client).All(context.Background())
+
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Len(t, all.Data, 5)
+	assert.Len(t, all.Included, 3)
+	assert.NotNil(t, all.Included[2].Build())
+	assert.Nil(t, all.Links.Next)
+}
+
+func TestPagerMaxItems(t *testing.T) {
+	t.Parallel()
+
+	client := newPagedServer(t, mockAppPages)
+
+	pager := newAppsPager(client)
+	pager.MaxItems = 3
+
+	all, _, err := pager.All(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, all.Data, 3)
+	assert.Len(t, all.Included, 2)
+}
+
+func TestPagerEmptyPage(t *testing.T) {
+	t.Parallel()
+
+	client := newPagedServer(t, []string{`[]`})
+
+	all, _, err := newAppsPager(client).All(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, all.Data)
+}
+
+func TestPagerContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	client := newPagedServer(t, mockAppPages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pager := newAppsPager(client)
+
+	assert.False(t, pager.Next(ctx))
+	assert.ErrorIs(t, pager.Err(), context.Canceled)
+	assert.Nil(t, pager.Page())
+	assert.Nil(t, pager.Paging())
+}
+
+func TestPagerError(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer(`{"errors":[{"code":"NOT_FOUND"}]}`, http.StatusNotFound, false)
+	defer server.Close()
+
+	all, _, err := newAppsPager(client).All(context.Background())
+
+	assert.Error(t, err)
+	assert.Nil(t, all)
+}
+
+func TestPagerInvalidPage(t *testing.T) {
+	t.Parallel()
+
+	client := newPagedServer(t, mockAppPages)
+
+	pager := NewPager[App](client, func(ctx context.Context) (*mockInvalidPage, *Response, error) {
+		res := new(mockInvalidPage)
+		resp, err := client.get(ctx, "apps", nil, res)
+
+		return res, resp, err
+	})
+
+	assert.False(t, pager.Next(context.Background()))
+	assert.Error(t, pager.Err())
+	assert.IsType(t, ErrInvalidPage{}, pager.Err())
+	assert.NotEmpty(t, pager.Err().Error())
+}
@@ -0,0 +1,292 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultPhasedReleaseInterval is how often PhasedReleaseController.Run checks a phased release
+// when it is not given an interval.
+const defaultPhasedReleaseInterval = time.Hour
+
+// phasedReleasePercentages is the share of users, in percent, that receive an update on each day
+// of a seven-day phased release.
+var phasedReleasePercentages = []int{1, 2, 5, 10, 20, 50, 100}
+
+// PhasedReleasePercentage returns the share of users, in percent, that App Store Connect offers
+// an update to on the given day of a phased release. Day 0 means the release has not started.
+func PhasedReleasePercentage(day int) int {
+	switch {
+	case day <= 0:
+		return 0
+	case day > len(phasedReleasePercentages):
+		return 100
+	default:
+		return phasedReleasePercentages[day-1]
+	}
+}
+
+// PhasedReleaseSnapshot is what a PhasedReleasePolicy knows about a phased release when it
+// decides what to do with it.
+type PhasedReleaseSnapshot struct {
+	PhasedRelease AppStoreVersionPhasedRelease
+	State         PhasedReleaseState
+	// Day is the current day of the phased release, from 1 to 7.
+	Day int
+	// Percentage is the share of users that are offered the update, as given by
+	// PhasedReleasePercentage.
+	Percentage int
+	// BuildID is the build attached to the released App Store version.
+	BuildID string
+	// Signatures are the diagnostic signatures captured for the build.
+	Signatures []DiagnosticSignature
+}
+
+// PhasedReleaseDecision is the outcome of a PhasedReleasePolicy.
+type PhasedReleaseDecision struct {
+	// State is the state the phased release should move to, such as PhasedReleaseStatePaused,
+	// PhasedReleaseStateActive to resume it, or PhasedReleaseStateComplete to release the update
+	// to every user. An empty State leaves the phased release unchanged.
+	State  PhasedReleaseState
+	Reason string
+}
+
+// PhasedReleasePolicy decides whether a phased release should continue, pause or complete.
+type PhasedReleasePolicy interface {
+	Evaluate(snapshot *PhasedReleaseSnapshot) PhasedReleaseDecision
+}
+
+// PhasedReleasePolicyFunc adapts a function to a PhasedReleasePolicy.
+type PhasedReleasePolicyFunc func(snapshot *PhasedReleaseSnapshot) PhasedReleaseDecision
+
+// Evaluate calls f(snapshot).
+func (f PhasedReleasePolicyFunc) Evaluate(snapshot *PhasedReleaseSnapshot) PhasedReleaseDecision {
+	return f(snapshot)
+}
+
+// PhasedReleaseThresholds is a PhasedReleasePolicy that pauses a phased release when the
+// diagnostic signatures of the build exceed any of its limits, and completes it once it has
+// reached CompleteAtPercentage without doing so. Limits that are zero are not checked.
+type PhasedReleaseThresholds struct {
+	// DiagnosticTypes restricts the signatures that are checked, such as "DISK_WRITES" or
+	// "HANGS". If empty, every signature is checked.
+	DiagnosticTypes []string
+	// MaxSignatures is the maximum number of signatures.
+	MaxSignatures int
+	// MaxSignatureWeight is the maximum weight of a single signature, as reported by App Store
+	// Connect.
+	MaxSignatureWeight float32
+	// MaxTotalWeight is the maximum sum of the weights of every signature.
+	MaxTotalWeight float32
+	// CompleteAtPercentage completes the phased release early once it offers the update to at
+	// least this share of users.
+	CompleteAtPercentage int
+}
+
+// Evaluate implements PhasedReleasePolicy.
+func (t PhasedReleaseThresholds) Evaluate(snapshot *PhasedReleaseSnapshot) PhasedReleaseDecision {
+	var (
+		count int
+		total float32
+	)
+
+	for _, signature := range snapshot.Signatures {
+		if signature.Attributes == nil {
+			continue
+		}
+
+		if len(t.DiagnosticTypes) > 0 && (signature.Attributes.DiagnosticType == nil || !contains(t.DiagnosticTypes, *signature.Attributes.DiagnosticType)) {
+			continue
+		}
+
+		var weight float32
+		if signature.Attributes.Weight != nil {
+			weight = *signature.Attributes.Weight
+		}
+
+		if t.MaxSignatureWeight > 0 && weight > t.MaxSignatureWeight {
+			return PhasedReleaseDecision{
+				State:  PhasedReleaseStatePaused,
+				Reason: fmt.Sprintf("signature %s has weight %g, above the limit of %g", signature.ID, weight, t.MaxSignatureWeight),
+			}
+		}
+
+		count++
+		total += weight
+	}
+
+	switch {
+	case t.MaxSignatures > 0 && count > t.MaxSignatures:
+		return PhasedReleaseDecision{
+			State:  PhasedReleaseStatePaused,
+			Reason: fmt.Sprintf("build has %d diagnostic signatures, above the limit of %d", count, t.MaxSignatures),
+		}
+	case t.MaxTotalWeight > 0 && total > t.MaxTotalWeight:
+		return PhasedReleaseDecision{
+			State:  PhasedReleaseStatePaused,
+			Reason: fmt.Sprintf("diagnostic signatures have a total weight of %g, above the limit of %g", total, t.MaxTotalWeight),
+		}
+	case t.CompleteAtPercentage > 0 && snapshot.Percentage >= t.CompleteAtPercentage:
+		return PhasedReleaseDecision{
+			State:  PhasedReleaseStateComplete,
+			Reason: fmt.Sprintf("phased release reached %d%% of users", snapshot.Percentage),
+		}
+	}
+
+	return PhasedReleaseDecision{}
+}
+
+// PhasedReleaseReport is the result of one check of a PhasedReleaseController.
+type PhasedReleaseReport struct {
+	Snapshot PhasedReleaseSnapshot
+	Decision PhasedReleaseDecision
+	// Applied reports whether the phased release was updated to the state of the decision. It is
+	// false in dry-run mode, and when the decision does not apply to the current state.
+	Applied bool
+}
+
+// PhasedReleaseController pauses, resumes or completes the phased release of an App Store
+// version based on a PhasedReleasePolicy, which sees the progress of the release along with the
+// diagnostic signatures of the released build.
+type PhasedReleaseController struct {
+	AppStoreVersionID string
+	Policy            PhasedReleasePolicy
+	// DryRun evaluates the policy without updating the phased release.
+	DryRun bool
+	// OnCheck, if set, is called by Run with the report of every check.
+	OnCheck func(report *PhasedReleaseReport)
+
+	client *Client
+}
+
+// NewPhasedReleaseController creates a PhasedReleaseController for the phased release of an
+// App Store version.
+func NewPhasedReleaseController(client *Client, appStoreVersionID string, policy PhasedReleasePolicy) *PhasedReleaseController {
+	return &PhasedReleaseController{
+		AppStoreVersionID: appStoreVersionID,
+		Policy:            policy,
+		client:            client,
+	}
+}
+
+// Check takes a snapshot of the phased release, evaluates the policy and, unless DryRun is set,
+// applies its decision. Decisions are only applied to a phased release that is active or paused,
+// and that is not already in the state decided on.
+func (c *PhasedReleaseController) Check(ctx context.Context) (*PhasedReleaseReport, error) {
+	snapshot, err := c.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PhasedReleaseReport{
+		Snapshot: *snapshot,
+		Decision: c.Policy.Evaluate(snapshot),
+	}
+
+	state := report.Decision.State
+	if c.DryRun || state == "" || state == snapshot.State ||
+		(snapshot.State != PhasedReleaseStateActive && snapshot.State != PhasedReleaseStatePaused) {
+		return report, nil
+	}
+
+	if _, _, err := c.client.Publishing.UpdatePhasedRelease(ctx, snapshot.PhasedRelease.ID, &state); err != nil {
+		return nil, err
+	}
+
+	report.Applied = true
+
+	return report, nil
+}
+
+// Run checks the phased release every interval until it is complete or the context is done. A
+// zero interval checks every hour.
+func (c *PhasedReleaseController) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = defaultPhasedReleaseInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := c.Check(ctx)
+		if err != nil {
+			return err
+		}
+
+		if c.OnCheck != nil {
+			c.OnCheck(report)
+		}
+
+		if report.Snapshot.State == PhasedReleaseStateComplete ||
+			(report.Applied && report.Decision.State == PhasedReleaseStateComplete) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *PhasedReleaseController) snapshot(ctx context.Context) (*PhasedReleaseSnapshot, error) {
+	release, _, err := c.client.Publishing.GetAppStoreVersionPhasedReleaseForAppStoreVersion(ctx, c.AppStoreVersionID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &PhasedReleaseSnapshot{PhasedRelease: release.Data}
+
+	if attributes := release.Data.Attributes; attributes != nil {
+		if attributes.PhasedReleaseState != nil {
+			snapshot.State = *attributes.PhasedReleaseState
+		}
+
+		if attributes.CurrentDayNumber != nil {
+			snapshot.Day = *attributes.CurrentDayNumber
+		}
+	}
+
+	snapshot.Percentage = PhasedReleasePercentage(snapshot.Day)
+
+	build, _, err := c.client.Builds.GetBuildForAppStoreVersion(ctx, c.AppStoreVersionID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.BuildID = build.Data.ID
+
+	signatures, _, err := NewPager[DiagnosticSignature](c.client, func(ctx context.Context) (*DiagnosticSignaturesResponse, *Response, error) {
+		return c.client.Reporting.ListDiagnosticSignaturesForBuild(ctx, snapshot.BuildID, &ListDiagnosticsSignaturesQuery{Limit: 200})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Signatures = signatures.Data
+
+	return snapshot, nil
+}
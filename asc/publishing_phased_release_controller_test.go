@@ -0,0 +1,172 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPhasedReleaseServer serves the phased release of App Store version "v1", whose build "b1"
+// has the given diagnostic signatures, and records the states it is updated to.
+type mockPhasedReleaseServer struct {
+	*routeServer
+
+	state   PhasedReleaseState
+	updates []PhasedReleaseState
+}
+
+func newMockPhasedReleaseServer(t *testing.T, state PhasedReleaseState, day int, signatures string) (*Client, *mockPhasedReleaseServer) {
+	t.Helper()
+
+	mock := &mockPhasedReleaseServer{state: state}
+
+	client, server := newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /appStoreVersions/v1/appStoreVersionPhasedRelease": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data":{"id":"p1","type":"appStoreVersionPhasedReleases","attributes":{"phasedReleaseState":%q,"currentDayNumber":%d}}}`, mock.state, day)
+		},
+		"GET /appStoreVersions/v1/build":      respond(`{"data":{"id":"b1","type":"builds"}}`),
+		"GET /builds/b1/diagnosticSignatures": respond(fmt.Sprintf(`{"data":[%s]}`, signatures)),
+		"PATCH /appStoreVersionPhasedReleases/p1": func(w http.ResponseWriter, r *http.Request) {
+			mock.state = PhasedReleaseState(requestAttributes(decodeRequest(r))["phasedReleaseState"].(string))
+			mock.updates = append(mock.updates, mock.state)
+			fmt.Fprint(w, `{"data":{"id":"p1","type":"appStoreVersionPhasedReleases"}}`)
+		},
+	})
+	mock.routeServer = server
+
+	return client, mock
+}
+
+const testDiagnosticSignatures = `
+	{"id":"s1","type":"diagnosticSignatures","attributes":{"diagnosticType":"HANGS","weight":12.5}},
+	{"id":"s2","type":"diagnosticSignatures","attributes":{"diagnosticType":"DISK_WRITES","weight":40}}`
+
+func TestPhasedReleasePercentage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, PhasedReleasePercentage(0))
+	assert.Equal(t, 1, PhasedReleasePercentage(1))
+	assert.Equal(t, 20, PhasedReleasePercentage(5))
+	assert.Equal(t, 100, PhasedReleasePercentage(7))
+	assert.Equal(t, 100, PhasedReleasePercentage(30))
+}
+
+func TestPhasedReleaseThresholds(t *testing.T) {
+	t.Parallel()
+
+	snapshot := &PhasedReleaseSnapshot{
+		Percentage: 20,
+		Signatures: []DiagnosticSignature{
+			{ID: "s1", Attributes: &DiagnosticSignatureAttributes{DiagnosticType: String("HANGS"), Weight: float32Ptr(12.5)}},
+			{ID: "s2", Attributes: &DiagnosticSignatureAttributes{DiagnosticType: String("DISK_WRITES"), Weight: float32Ptr(40)}},
+		},
+	}
+
+	assert.Equal(t, PhasedReleaseDecision{}, PhasedReleaseThresholds{}.Evaluate(snapshot))
+	assert.Equal(t, PhasedReleaseDecision{
+		State:  PhasedReleaseStatePaused,
+		Reason: "signature s2 has weight 40, above the limit of 30",
+	}, PhasedReleaseThresholds{MaxSignatureWeight: 30}.Evaluate(snapshot))
+	assert.Equal(t, PhasedReleaseDecision{}, PhasedReleaseThresholds{
+		DiagnosticTypes:    []string{"HANGS"},
+		MaxSignatureWeight: 30,
+	}.Evaluate(snapshot))
+	assert.Equal(t, PhasedReleaseStatePaused, PhasedReleaseThresholds{MaxSignatures: 1}.Evaluate(snapshot).State)
+	assert.Equal(t, PhasedReleaseStatePaused, PhasedReleaseThresholds{MaxTotalWeight: 50}.Evaluate(snapshot).State)
+	assert.Equal(t, PhasedReleaseDecision{
+		State:  PhasedReleaseStateComplete,
+		Reason: "phased release reached 20% of users",
+	}, PhasedReleaseThresholds{MaxTotalWeight: 60, CompleteAtPercentage: 20}.Evaluate(snapshot))
+}
+
+func TestPhasedReleaseControllerCheck(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockPhasedReleaseServer(t, PhasedReleaseStateActive, 3, testDiagnosticSignatures)
+
+	controller := NewPhasedReleaseController(client, "v1", PhasedReleaseThresholds{MaxSignatureWeight: 30})
+	controller.DryRun = true
+
+	report, err := controller.Check(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, report.Applied)
+	assert.Equal(t, PhasedReleaseStatePaused, report.Decision.State)
+	assert.Equal(t, 5, report.Snapshot.Percentage)
+	assert.Equal(t, "b1", report.Snapshot.BuildID)
+	assert.Len(t, report.Snapshot.Signatures, 2)
+	assert.Empty(t, server.updates)
+
+	controller.DryRun = false
+
+	report, err = controller.Check(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, report.Applied)
+	assert.Equal(t, []PhasedReleaseState{PhasedReleaseStatePaused}, server.updates)
+
+	report, err = controller.Check(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, report.Applied)
+	assert.Len(t, server.updates, 1)
+}
+
+func TestPhasedReleaseControllerRun(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockPhasedReleaseServer(t, PhasedReleaseStatePaused, 5, "")
+
+	resume := PhasedReleasePolicyFunc(func(snapshot *PhasedReleaseSnapshot) PhasedReleaseDecision {
+		if snapshot.State == PhasedReleaseStatePaused {
+			return PhasedReleaseDecision{State: PhasedReleaseStateActive, Reason: "no regressions"}
+		}
+
+		return PhasedReleaseThresholds{CompleteAtPercentage: 20}.Evaluate(snapshot)
+	})
+
+	var checks int
+
+	controller := NewPhasedReleaseController(client, "v1", resume)
+	controller.OnCheck = func(report *PhasedReleaseReport) {
+		checks++
+	}
+
+	assert.NoError(t, controller.Run(context.Background(), time.Millisecond))
+	assert.Equal(t, 2, checks)
+	assert.Equal(t, []PhasedReleaseState{PhasedReleaseStateActive, PhasedReleaseStateComplete}, server.updates)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	controller = NewPhasedReleaseController(client, "v2", resume)
+	assert.Error(t, controller.Run(ctx, time.Millisecond))
+	assert.NotPanics(t, func() {
+		assert.Error(t, controller.Run(ctx, 0))
+	})
+}
+
+func float32Ptr(v float32) *float32 {
+	return &v
+}
@@ -0,0 +1,142 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"time"
+)
+
+// maxPreOrderDays is how far in the future the release date of a pre-order can be.
+const maxPreOrderDays = 180
+
+// PreOrderChange describes what SchedulePreOrder did to the pre-order of an app.
+type PreOrderChange struct {
+	PreOrder AppPreOrder
+	// Created reports whether the pre-order did not exist and was created.
+	Created bool
+	// Updated reports whether the release date of an existing pre-order was changed.
+	Updated bool
+	// OldReleaseDate is the release date before the change, or nil if the pre-order was created.
+	OldReleaseDate *Date
+	// NewReleaseDate is the release date after the change.
+	NewReleaseDate *Date
+	// OldAvailableDate is the date the app became available for pre-order before the change.
+	OldAvailableDate *Date
+	// NewAvailableDate is the date the app is available for pre-order after the change.
+	NewAvailableDate *Date
+}
+
+// AvailableDateChanged reports whether the date the app is available for pre-order changed.
+func (c *PreOrderChange) AvailableDateChanged() bool {
+	return !sameDate(c.OldAvailableDate, c.NewAvailableDate)
+}
+
+// ValidatePreOrderReleaseDate checks that the release date of a pre-order is in the future, and
+// no more than 180 days away. It returns a *ValidationError otherwise.
+func ValidatePreOrderReleaseDate(releaseDate Date) error {
+	return validatePreOrderReleaseDate(releaseDate, time.Now())
+}
+
+func validatePreOrderReleaseDate(releaseDate Date, now time.Time) error {
+	today := calendarDate(now.UTC())
+	days := int(calendarDate(releaseDate.Time).Sub(today).Hours() / 24)
+
+	v := validator{prefix: attributesPointer}
+
+	switch {
+	case days < 1:
+		v.add("appReleaseDate", ViolationInvalidDate, "appReleaseDate %s is not in the future", releaseDate.Format(dateFormat))
+	case days > maxPreOrderDays:
+		v.add("appReleaseDate", ViolationInvalidDate, "appReleaseDate is %d days away, but the limit is %d", days, maxPreOrderDays)
+	}
+
+	return v.err()
+}
+
+// SchedulePreOrder makes an app available for pre-order until the given release date. The
+// release date is validated with ValidatePreOrderReleaseDate first. If the app already has a
+// pre-order, its release date is updated instead, and nothing is changed if it already matches.
+func (s *PublishingService) SchedulePreOrder(ctx context.Context, appID string, releaseDate Date) (*PreOrderChange, error) {
+	if err := ValidatePreOrderReleaseDate(releaseDate); err != nil {
+		return nil, err
+	}
+
+	existing, _, err := s.GetPreOrderForApp(ctx, appID, nil)
+	if err = ignoreNotFound(err); err != nil {
+		return nil, err
+	}
+
+	if existing == nil || existing.Data.ID == "" {
+		res, _, err := s.CreatePreOrder(ctx, &releaseDate, appID)
+		if err != nil {
+			return nil, err
+		}
+
+		change := &PreOrderChange{PreOrder: res.Data, Created: true, NewReleaseDate: &releaseDate}
+		if res.Data.Attributes != nil {
+			change.NewAvailableDate = res.Data.Attributes.PreOrderAvailableDate
+		}
+
+		return change, nil
+	}
+
+	change := &PreOrderChange{PreOrder: existing.Data}
+	if attributes := existing.Data.Attributes; attributes != nil {
+		change.OldReleaseDate = attributes.AppReleaseDate
+		change.OldAvailableDate = attributes.PreOrderAvailableDate
+	}
+
+	change.NewReleaseDate = change.OldReleaseDate
+	change.NewAvailableDate = change.OldAvailableDate
+
+	if sameDate(change.OldReleaseDate, &releaseDate) {
+		return change, nil
+	}
+
+	res, _, err := s.UpdatePreOrder(ctx, existing.Data.ID, &releaseDate)
+	if err != nil {
+		return nil, err
+	}
+
+	change.PreOrder = res.Data
+	change.Updated = true
+	change.NewReleaseDate = &releaseDate
+
+	if res.Data.Attributes != nil && res.Data.Attributes.PreOrderAvailableDate != nil {
+		change.NewAvailableDate = res.Data.Attributes.PreOrderAvailableDate
+	}
+
+	return change, nil
+}
+
+// calendarDate returns midnight UTC of the calendar date of t, ignoring its location.
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func sameDate(a, b *Date) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return calendarDate(a.Time).Equal(calendarDate(b.Time))
+}
@@ -0,0 +1,116 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockPreOrderServer serves the pre-order of app "1", if it has one. Creating or updating it
+// moves the date the app is available for pre-order to today.
+func newMockPreOrderServer(t *testing.T, preOrder string) (*Client, *routeServer) {
+	t.Helper()
+
+	get := notFound
+	if preOrder != "" {
+		get = respond(preOrder)
+	}
+
+	saved := respond(fmt.Sprintf(`{"data":{"id":"p1","type":"appPreOrders","attributes":{"preOrderAvailableDate":%q}}}`, time.Now().UTC().Format(dateFormat)))
+
+	return newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /apps/1/preOrder":   get,
+		"POST /appPreOrders":     saved,
+		"PATCH /appPreOrders/p1": saved,
+	})
+}
+
+func daysFromNow(days int) Date {
+	return Date{time.Now().UTC().AddDate(0, 0, days)}
+}
+
+func TestValidatePreOrderReleaseDate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, validatePreOrderReleaseDate(Date{time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)}, now))
+	assert.NoError(t, validatePreOrderReleaseDate(Date{time.Date(2021, 6, 30, 0, 0, 0, 0, time.UTC)}, now))
+
+	violations := violationsOf(t, validatePreOrderReleaseDate(Date{time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}, now))
+	assert.Equal(t, []Violation{{
+		Pointer: "/data/attributes/appReleaseDate",
+		Code:    ViolationInvalidDate,
+		Detail:  "appReleaseDate 2021-01-01 is not in the future",
+	}}, violations)
+
+	violations = violationsOf(t, validatePreOrderReleaseDate(Date{time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)}, now))
+	assert.Equal(t, "appReleaseDate is 181 days away, but the limit is 180", violations[0].Detail)
+}
+
+func TestSchedulePreOrderCreate(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockPreOrderServer(t, "")
+
+	change, err := client.Publishing.SchedulePreOrder(context.Background(), "1", daysFromNow(30))
+	assert.NoError(t, err)
+	assert.True(t, change.Created)
+	assert.Nil(t, change.OldReleaseDate)
+	assert.True(t, change.AvailableDateChanged())
+	assert.Equal(t, []string{"POST /appPreOrders"}, server.changes())
+
+	_, err = client.Publishing.SchedulePreOrder(context.Background(), "1", daysFromNow(200))
+	assert.Error(t, err)
+	assert.Len(t, server.changes(), 1)
+}
+
+func TestSchedulePreOrderUpdate(t *testing.T) {
+	t.Parallel()
+
+	releaseDate := daysFromNow(30)
+	yesterday := daysFromNow(-1)
+
+	client, server := newMockPreOrderServer(t, fmt.Sprintf(
+		`{"data":{"id":"p1","type":"appPreOrders","attributes":{"appReleaseDate":%q,"preOrderAvailableDate":%q}}}`,
+		releaseDate.Format(dateFormat), yesterday.Format(dateFormat),
+	))
+
+	change, err := client.Publishing.SchedulePreOrder(context.Background(), "1", releaseDate)
+	assert.NoError(t, err)
+	assert.False(t, change.Created)
+	assert.False(t, change.Updated)
+	assert.False(t, change.AvailableDateChanged())
+	assert.Empty(t, server.changes())
+
+	change, err = client.Publishing.SchedulePreOrder(context.Background(), "1", daysFromNow(60))
+	assert.NoError(t, err)
+	assert.True(t, change.Updated)
+	assert.Equal(t, releaseDate.Format(dateFormat), change.OldReleaseDate.Format(dateFormat))
+	assert.True(t, change.AvailableDateChanged())
+	assert.Equal(t, []string{"PATCH /appPreOrders/p1"}, server.changes())
+}
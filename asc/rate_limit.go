@@ -0,0 +1,179 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	headerRetryAfter = "Retry-After"
+
+	rateLimitWindow = time.Hour
+)
+
+// RateGovernor paces requests made by a Client so that the hourly request budget reported by
+// the X-Rate-Limit header is not exhausted. While the remaining budget is above Reserve, requests
+// are sent immediately. Once it drops to Reserve or below, requests are queued and spaced out
+// evenly over the rate limit window. After a 429 response, all requests are held until the time
+// given by the response's Retry-After header.
+//
+// A RateGovernor is safe for concurrent use, and can be shared by multiple Clients that use
+// the same API key.
+type RateGovernor struct {
+	// Reserve is the number of remaining requests at or below which the governor starts
+	// pacing requests.
+	Reserve int
+	// OnRateChange, if set, is called when a response reports a Rate that differs from the last
+	// one observed. Responses without rate limit information are ignored.
+	OnRateChange func(Rate)
+
+	mu         sync.Mutex
+	rate       Rate
+	nextSlot   time.Time
+	retryAfter time.Time
+	now        func() time.Time
+}
+
+// NewRateGovernor creates a new RateGovernor that starts pacing requests when the remaining
+// budget reaches reserve.
+func NewRateGovernor(reserve int) *RateGovernor {
+	return &RateGovernor{
+		Reserve: reserve,
+		now:     time.Now,
+	}
+}
+
+// SetRateGovernor sets the RateGovernor used to pace requests made by this client. Passing nil
+// disables pacing.
+func (c *Client) SetRateGovernor(g *RateGovernor) {
+	c.governor = g
+}
+
+// Rate returns the most recently observed rate limit budget. The zero value is returned if
+// no response with rate limit information has been observed yet.
+func (g *RateGovernor) Rate() Rate {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.rate
+}
+
+// Wait blocks until a request is allowed to be sent, or the context is done.
+func (g *RateGovernor) Wait(ctx context.Context) error {
+	delay := g.claim()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// claim claims the next available request slot and returns how long the caller has to wait for it.
+func (g *RateGovernor) claim() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock()
+	start := now
+
+	if g.retryAfter.After(start) {
+		start = g.retryAfter
+	}
+
+	if g.rate.Limit > 0 && g.rate.Remaining <= g.Reserve {
+		if g.nextSlot.After(start) {
+			start = g.nextSlot
+		}
+
+		g.nextSlot = start.Add(rateLimitWindow / time.Duration(g.rate.Limit))
+	}
+
+	return start.Sub(now)
+}
+
+// Observe records the rate limit information and Retry-After header of a response.
+func (g *RateGovernor) Observe(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	rate := parseRate(resp)
+
+	g.mu.Lock()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if until, ok := parseRetryAfter(resp.Header.Get(headerRetryAfter), g.clock()); ok && until.After(g.retryAfter) {
+			g.retryAfter = until
+		}
+	}
+
+	changed := rate != (Rate{}) && rate != g.rate
+	if changed {
+		g.rate = rate
+	}
+
+	callback := g.OnRateChange
+
+	g.mu.Unlock()
+
+	if changed && callback != nil {
+		callback(rate)
+	}
+}
+
+func (g *RateGovernor) clock() time.Time {
+	if g.now == nil {
+		return time.Now()
+	}
+
+	return g.now()
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(header string, now time.Time) (time.Time, bool) {
+	if header == "" {
+		return time.Time{}, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return date, true
+	}
+
+	return time.Time{}, false
+}
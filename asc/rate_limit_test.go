@@ -0,0 +1,167 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRateGovernor(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(nil)
+	governor := NewRateGovernor(10)
+	client.SetRateGovernor(governor)
+	assert.Same(t, governor, client.governor)
+	client.SetRateGovernor(nil)
+	assert.Nil(t, client.governor)
+}
+
+func TestRateGovernorObservesResponses(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer(marshaledMockPayload, http.StatusOK, true)
+	defer server.Close()
+
+	var observed []Rate
+
+	governor := NewRateGovernor(0)
+	governor.OnRateChange = func(rate Rate) {
+		observed = append(observed, rate)
+	}
+	client.SetRateGovernor(governor)
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+	assert.NoError(t, err)
+	_, err = client.get(context.Background(), "test", nil, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, Rate{Limit: 2500, Remaining: 10}, governor.Rate())
+	assert.Equal(t, []Rate{{Limit: 2500, Remaining: 10}}, observed)
+}
+
+func TestRateGovernorPacesBelowReserve(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	governor := NewRateGovernor(10)
+	governor.now = func() time.Time { return now }
+
+	assert.Zero(t, governor.claim(), "no pacing before a rate is observed")
+
+	governor.Observe(&http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"X-Rate-Limit": []string{"user-hour-lim:3600;user-hour-rem:100"}},
+	})
+	assert.Zero(t, governor.claim(), "no pacing above the reserve")
+
+	governor.Observe(&http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"X-Rate-Limit": []string{"user-hour-lim:3600;user-hour-rem:5"}},
+	})
+	assert.Equal(t, time.Duration(0), governor.claim())
+	assert.Equal(t, time.Second, governor.claim())
+	assert.Equal(t, 2*time.Second, governor.claim())
+}
+
+func TestRateGovernorHonorsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	governor := NewRateGovernor(0)
+	governor.now = func() time.Time { return now }
+
+	governor.Observe(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	})
+	assert.Equal(t, 30*time.Second, governor.claim())
+
+	governor.Observe(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{now.Add(time.Minute).Format(http.TimeFormat)}},
+	})
+	assert.Equal(t, time.Minute, governor.claim())
+
+	governor.Observe(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"soon"}},
+	})
+	assert.Equal(t, time.Minute, governor.claim())
+
+	governor.Observe(nil)
+}
+
+func TestRateGovernorWaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	governor := NewRateGovernor(0)
+	governor.Observe(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"3600"}},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, governor.Wait(ctx), context.Canceled)
+}
+
+func TestRateGovernorWaitCanceledDuringRequest(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer(marshaledMockPayload, http.StatusOK, true)
+	defer server.Close()
+
+	governor := NewRateGovernor(0)
+	governor.Observe(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"3600"}},
+	})
+	client.SetRateGovernor(governor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	resp, err := client.get(ctx, "test", nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, resp)
+}
+
+func TestRateGovernorWaitElapses(t *testing.T) {
+	t.Parallel()
+
+	governor := NewRateGovernor(0)
+	governor.Observe(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"0"}},
+	})
+
+	assert.NoError(t, governor.Wait(context.Background()))
+
+	governor.retryAfter = time.Now().Add(time.Millisecond)
+	assert.NoError(t, governor.Wait(context.Background()))
+}
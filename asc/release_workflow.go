@@ -0,0 +1,386 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrReleasePrecondition happens when a ReleaseWorkflow cannot continue because App Store
+// Connect is not in the state the next step requires, such as a build that is still processing.
+type ErrReleasePrecondition struct {
+	Step   ReleaseStep
+	Reason string
+}
+
+func (e ErrReleasePrecondition) Error() string {
+	return fmt.Sprintf("release step %s cannot run: %s", e.Step, e.Reason)
+}
+
+// ReleaseStep is a step of a ReleaseWorkflow.
+type ReleaseStep string
+
+// Steps of a ReleaseWorkflow, in the order they run.
+const (
+	// ReleaseStepVersion finds or creates the App Store version.
+	ReleaseStepVersion ReleaseStep = "version"
+	// ReleaseStepBuild attaches the build to the App Store version.
+	ReleaseStepBuild ReleaseStep = "build"
+	// ReleaseStepReviewDetail creates or updates the App Store review details.
+	ReleaseStepReviewDetail ReleaseStep = "reviewDetail"
+	// ReleaseStepIDFADeclaration creates or updates the IDFA declaration.
+	ReleaseStepIDFADeclaration ReleaseStep = "idfaDeclaration"
+	// ReleaseStepPhasedRelease enables a phased release.
+	ReleaseStepPhasedRelease ReleaseStep = "phasedRelease"
+	// ReleaseStepSubmission submits the App Store version to App Review.
+	ReleaseStepSubmission ReleaseStep = "submission"
+)
+
+// ReleaseSpec describes the App Store release a ReleaseWorkflow makes.
+type ReleaseSpec struct {
+	AppID         string
+	Platform      Platform
+	VersionString string
+	// BuildID is the build to release. It must have finished processing, and its export
+	// compliance information must have been provided.
+	BuildID             string
+	Copyright           *string
+	ReleaseType         *string
+	EarliestReleaseDate *DateTime
+	// ReviewDetail holds the contact and demo account information for App Review. If nil, the
+	// review details are left unchanged.
+	ReviewDetail *AppStoreReviewDetailCreateRequestAttributes
+	// IDFADeclaration declares the use of the advertising identifier. If nil, no declaration
+	// is made.
+	IDFADeclaration *IDFADeclarationCreateRequestAttributes
+	// PhasedRelease releases the version gradually over seven days once it is released.
+	PhasedRelease bool
+	// Submit submits the version to App Review as the last step.
+	Submit bool
+}
+
+// ReleaseState records the progress of a ReleaseWorkflow. It can be saved after each step,
+// such as with ReleaseWorkflow.OnStep, and given to a new workflow to resume a partially
+// completed run.
+type ReleaseState struct {
+	AppStoreVersionID string        `json:"appStoreVersionId,omitempty"`
+	ReviewDetailID    string        `json:"reviewDetailId,omitempty"`
+	IDFADeclarationID string        `json:"idfaDeclarationId,omitempty"`
+	PhasedReleaseID   string        `json:"phasedReleaseId,omitempty"`
+	SubmissionID      string        `json:"submissionId,omitempty"`
+	Completed         []ReleaseStep `json:"completed,omitempty"`
+}
+
+// IsCompleted reports whether the given step has completed.
+func (s *ReleaseState) IsCompleted(step ReleaseStep) bool {
+	for _, completed := range s.Completed {
+		if completed == step {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReleaseWorkflow creates an App Store version for a build and prepares it for release, step by
+// step: it finds or creates the version, attaches the build, sets the review details and IDFA
+// declaration, enables a phased release and submits the version to App Review.
+//
+// Every step first checks whether its work has already been done, so running a workflow again
+// after a failure, or with the State of an earlier run, only performs the remaining steps.
+type ReleaseWorkflow struct {
+	Spec  ReleaseSpec
+	State ReleaseState
+	// OnStep is called after each step completes, with the updated State.
+	OnStep func(step ReleaseStep, state ReleaseState)
+
+	client    *Client
+	submitted bool
+}
+
+// NewReleaseWorkflow creates a ReleaseWorkflow for the given release. Set State on the returned
+// workflow to resume an earlier run.
+func NewReleaseWorkflow(client *Client, spec ReleaseSpec) *ReleaseWorkflow {
+	return &ReleaseWorkflow{Spec: spec, client: client}
+}
+
+// Run runs every step of the workflow that has not completed yet. If the version is already
+// waiting for or in review and Spec.Submit is set, the release is considered done. It returns an
+// ErrReleasePrecondition if App Store Connect is not ready for a step, or the error of the
+// first request that failed.
+func (w *ReleaseWorkflow) Run(ctx context.Context) error {
+	steps := []struct {
+		step    ReleaseStep
+		enabled bool
+		run     func(ctx context.Context) error
+	}{
+		{ReleaseStepVersion, true, w.version},
+		{ReleaseStepBuild, true, w.build},
+		{ReleaseStepReviewDetail, w.Spec.ReviewDetail != nil, w.reviewDetail},
+		{ReleaseStepIDFADeclaration, w.Spec.IDFADeclaration != nil, w.idfaDeclaration},
+		{ReleaseStepPhasedRelease, w.Spec.PhasedRelease, w.phasedRelease},
+		{ReleaseStepSubmission, w.Spec.Submit, w.submission},
+	}
+
+	for _, s := range steps {
+		completed := w.State.IsCompleted(s.step)
+
+		// The version step always runs so that its state is checked again on resume.
+		if !s.enabled || (completed && s.step != ReleaseStepVersion) {
+			continue
+		}
+
+		if err := s.run(ctx); err != nil {
+			return err
+		}
+
+		if !completed {
+			w.State.Completed = append(w.State.Completed, s.step)
+		}
+
+		if w.OnStep != nil {
+			w.OnStep(s.step, w.State)
+		}
+
+		if w.submitted {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (w *ReleaseWorkflow) version(ctx context.Context) error {
+	var version *AppStoreVersion
+
+	if w.State.AppStoreVersionID != "" {
+		res, _, err := w.client.Apps.GetAppStoreVersion(ctx, w.State.AppStoreVersionID, nil)
+		if err != nil {
+			return err
+		}
+
+		version = &res.Data
+	} else {
+		res, _, err := w.client.Apps.ListAppStoreVersionsForApp(ctx, w.Spec.AppID, &ListAppStoreVersionsQuery{
+			FilterVersionString: []string{w.Spec.VersionString},
+			FilterPlatform:      []string{string(w.Spec.Platform)},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(res.Data) > 0 {
+			version = &res.Data[0]
+		}
+	}
+
+	if version == nil {
+		res, _, err := w.client.Apps.CreateAppStoreVersion(ctx, AppStoreVersionCreateRequestAttributes{
+			Copyright:           w.Spec.Copyright,
+			EarliestReleaseDate: w.Spec.EarliestReleaseDate,
+			Platform:            w.Spec.Platform,
+			ReleaseType:         w.Spec.ReleaseType,
+			VersionString:       w.Spec.VersionString,
+		}, w.Spec.AppID, nil)
+		if err != nil {
+			return err
+		}
+
+		version = &res.Data
+	}
+
+	w.State.AppStoreVersionID = version.ID
+
+	if version.Attributes != nil && version.Attributes.AppStoreState != nil {
+		switch state := *version.Attributes.AppStoreState; state {
+		case AppStoreVersionStatePrepareForSubmission:
+		case AppStoreVersionStateWaitingForReview, AppStoreVersionStateInReview:
+			// An earlier run submitted the version but did not record it.
+			w.submitted = w.Spec.Submit
+			if !w.submitted {
+				return ErrReleasePrecondition{
+					Step:   ReleaseStepVersion,
+					Reason: fmt.Sprintf("version %s has already been submitted for review", w.Spec.VersionString),
+				}
+			}
+		default:
+			return ErrReleasePrecondition{
+				Step:   ReleaseStepVersion,
+				Reason: fmt.Sprintf("version %s is in state %s, not %s", w.Spec.VersionString, state, AppStoreVersionStatePrepareForSubmission),
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *ReleaseWorkflow) build(ctx context.Context) error {
+	res, _, err := w.client.Builds.GetBuild(ctx, w.Spec.BuildID, nil)
+	if err != nil {
+		return err
+	}
+
+	attributes := res.Data.Attributes
+	if attributes == nil || attributes.ProcessingState == nil || *attributes.ProcessingState != BuildProcessingStateValid {
+		return ErrReleasePrecondition{
+			Step:   ReleaseStepBuild,
+			Reason: fmt.Sprintf("build %s has not finished processing", w.Spec.BuildID),
+		}
+	}
+
+	if attributes.UsesNonExemptEncryption == nil {
+		return ErrReleasePrecondition{
+			Step:   ReleaseStepBuild,
+			Reason: fmt.Sprintf("build %s is missing export compliance information", w.Spec.BuildID),
+		}
+	}
+
+	linkage, _, err := w.client.Apps.GetBuildIDForAppStoreVersion(ctx, w.State.AppStoreVersionID)
+	if err = ignoreNotFound(err); err != nil {
+		return err
+	}
+
+	if linkage != nil && linkage.Data.ID == w.Spec.BuildID {
+		return nil
+	}
+
+	_, _, err = w.client.Apps.UpdateBuildForAppStoreVersion(ctx, w.State.AppStoreVersionID, &w.Spec.BuildID)
+
+	return err
+}
+
+func (w *ReleaseWorkflow) reviewDetail(ctx context.Context) error {
+	detail := w.Spec.ReviewDetail
+	attributes := AppStoreReviewDetailUpdateRequestAttributes(*detail)
+
+	if err := ValidateAppStoreReviewDetail(&attributes); err != nil {
+		return err
+	}
+
+	res, _, err := w.client.Submission.GetReviewDetailsForAppStoreVersion(ctx, w.State.AppStoreVersionID, nil)
+	if err = ignoreNotFound(err); err != nil {
+		return err
+	}
+
+	if res != nil && res.Data.ID != "" {
+		w.State.ReviewDetailID = res.Data.ID
+		_, _, err = w.client.Submission.UpdateReviewDetail(ctx, res.Data.ID, &attributes)
+
+		return err
+	}
+
+	created, _, err := w.client.Submission.CreateReviewDetail(ctx, detail, w.State.AppStoreVersionID)
+	if err != nil {
+		return err
+	}
+
+	w.State.ReviewDetailID = created.Data.ID
+
+	return nil
+}
+
+func (w *ReleaseWorkflow) idfaDeclaration(ctx context.Context) error {
+	declaration := w.Spec.IDFADeclaration
+
+	res, _, err := w.client.Submission.GetIDFADeclarationForAppStoreVersion(ctx, w.State.AppStoreVersionID, nil)
+	if err = ignoreNotFound(err); err != nil {
+		return err
+	}
+
+	if res != nil && res.Data.ID != "" {
+		w.State.IDFADeclarationID = res.Data.ID
+		_, _, err = w.client.Submission.UpdateIDFADeclaration(ctx, res.Data.ID, &IDFADeclarationUpdateRequestAttributes{
+			AttributesActionWithPreviousAd:        Bool(declaration.AttributesActionWithPreviousAd),
+			AttributesAppInstallationToPreviousAd: Bool(declaration.AttributesAppInstallationToPreviousAd),
+			HonorsLimitedAdTracking:               Bool(declaration.HonorsLimitedAdTracking),
+			ServesAds:                             Bool(declaration.ServesAds),
+		})
+
+		return err
+	}
+
+	created, _, err := w.client.Submission.CreateIDFADeclaration(ctx, *declaration, w.State.AppStoreVersionID)
+	if err != nil {
+		return err
+	}
+
+	w.State.IDFADeclarationID = created.Data.ID
+
+	return nil
+}
+
+func (w *ReleaseWorkflow) phasedRelease(ctx context.Context) error {
+	res, _, err := w.client.Publishing.GetAppStoreVersionPhasedReleaseForAppStoreVersion(ctx, w.State.AppStoreVersionID, nil)
+	if err = ignoreNotFound(err); err != nil {
+		return err
+	}
+
+	if res != nil && res.Data.ID != "" {
+		w.State.PhasedReleaseID = res.Data.ID
+
+		return nil
+	}
+
+	state := PhasedReleaseStateInactive
+
+	created, _, err := w.client.Publishing.CreatePhasedRelease(ctx, &state, w.State.AppStoreVersionID)
+	if err != nil {
+		return err
+	}
+
+	w.State.PhasedReleaseID = created.Data.ID
+
+	return nil
+}
+
+func (w *ReleaseWorkflow) submission(ctx context.Context) error {
+	res, _, err := w.client.Submission.GetAppStoreVersionSubmissionForAppStoreVersion(ctx, w.State.AppStoreVersionID, nil)
+	if err = ignoreNotFound(err); err != nil {
+		return err
+	}
+
+	if res != nil && res.Data.ID != "" {
+		w.State.SubmissionID = res.Data.ID
+
+		return nil
+	}
+
+	created, _, err := w.client.Submission.CreateSubmission(ctx, w.State.AppStoreVersionID)
+	if err != nil {
+		return err
+	}
+
+	w.State.SubmissionID = created.Data.ID
+
+	return nil
+}
+
+// ignoreNotFound discards a 404 error. App Store Connect signals a missing to-one relationship
+// either with a 404 or with an empty resource.
+func ignoreNotFound(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
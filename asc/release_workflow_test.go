@@ -0,0 +1,265 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockReleaseServer serves the resources of a release of app "1" and build "b1", creating them
+// as the workflow asks.
+type mockReleaseServer struct {
+	*routeServer
+
+	versionState    AppStoreVersionState
+	buildState      string
+	usesEncryption  *bool
+	resources       map[string]string
+	linkedBuildID   string
+	versionsCreated int
+}
+
+func newMockReleaseServer(t *testing.T) (*Client, *mockReleaseServer) {
+	t.Helper()
+
+	mock := &mockReleaseServer{
+		versionState:   AppStoreVersionStatePrepareForSubmission,
+		buildState:     BuildProcessingStateValid,
+		usesEncryption: Bool(false),
+		resources:      make(map[string]string),
+	}
+
+	version := func() string {
+		return fmt.Sprintf(`{"id":"v1","type":"appStoreVersions","attributes":{"appStoreState":%q}}`, mock.versionState)
+	}
+
+	routes := map[string]http.HandlerFunc{
+		"GET /apps/1/appStoreVersions": func(w http.ResponseWriter, r *http.Request) {
+			if mock.versionsCreated == 0 {
+				fmt.Fprint(w, `{"data":[]}`)
+			} else {
+				fmt.Fprintf(w, `{"data":[%s]}`, version())
+			}
+		},
+		"POST /appStoreVersions": func(w http.ResponseWriter, r *http.Request) {
+			mock.versionsCreated++
+			fmt.Fprintf(w, `{"data":%s}`, version())
+		},
+		"GET /appStoreVersions/v1": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data":%s}`, version())
+		},
+		"GET /builds/b1": func(w http.ResponseWriter, r *http.Request) {
+			if mock.buildState == "" {
+				fmt.Fprint(w, `{"data":{"id":"b1","type":"builds"}}`)
+
+				return
+			}
+
+			encryption, _ := json.Marshal(mock.usesEncryption)
+			fmt.Fprintf(w, `{"data":{"id":"b1","type":"builds","attributes":{"processingState":%q,"usesNonExemptEncryption":%s}}}`, mock.buildState, encryption)
+		},
+		"GET /appStoreVersions/v1/relationships/build": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data":{"id":%q,"type":"builds"}}`, mock.linkedBuildID)
+		},
+		"PATCH /appStoreVersions/v1/relationships/build": func(w http.ResponseWriter, r *http.Request) {
+			mock.linkedBuildID = "b1"
+			fmt.Fprint(w, `{"data":{"id":"b1","type":"builds"}}`)
+		},
+	}
+
+	// Each resource of the release is served once created, and can then be updated.
+	for relationship, kind := range map[string]string{
+		"appStoreReviewDetail":         "appStoreReviewDetails",
+		"idfaDeclaration":              "idfaDeclarations",
+		"appStoreVersionPhasedRelease": "appStoreVersionPhasedReleases",
+		"appStoreVersionSubmission":    "appStoreVersionSubmissions",
+	} {
+		kind := kind
+
+		routes["GET /appStoreVersions/v1/"+relationship] = func(w http.ResponseWriter, r *http.Request) {
+			id, ok := mock.resources[kind]
+			if !ok {
+				notFound(w, r)
+
+				return
+			}
+
+			fmt.Fprintf(w, `{"data":{"id":%q,"type":%q}}`, id, kind)
+		}
+		routes["POST /"+kind] = func(w http.ResponseWriter, r *http.Request) {
+			mock.resources[kind] = kind + "-1"
+			fmt.Fprintf(w, `{"data":{"id":%q,"type":%q}}`, mock.resources[kind], kind)
+
+			if kind == "appStoreVersionSubmissions" {
+				mock.versionState = AppStoreVersionStateWaitingForReview
+			}
+		}
+		routes["PATCH /"+kind+"/"+kind+"-1"] = respond(`{"data":{"id":"updated"}}`)
+	}
+
+	client, server := newRouteServer(t, routes)
+	mock.routeServer = server
+
+	return client, mock
+}
+
+func testReleaseSpec() ReleaseSpec {
+	return ReleaseSpec{
+		AppID:         "1",
+		Platform:      PlatformIOS,
+		VersionString: "1.0",
+		BuildID:       "b1",
+		ReviewDetail: &AppStoreReviewDetailCreateRequestAttributes{
+			ContactFirstName: String("Jane"),
+			ContactLastName:  String("Doe"),
+			ContactPhone:     String("+1 555 0100"),
+			ContactEmail:     String("jane@example.com"),
+		},
+		IDFADeclaration: &IDFADeclarationCreateRequestAttributes{ServesAds: true},
+		PhasedRelease:   true,
+		Submit:          true,
+	}
+}
+
+func TestReleaseWorkflowRun(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockReleaseServer(t)
+
+	var steps []ReleaseStep
+
+	workflow := NewReleaseWorkflow(client, testReleaseSpec())
+	workflow.OnStep = func(step ReleaseStep, state ReleaseState) {
+		steps = append(steps, step)
+	}
+
+	assert.NoError(t, workflow.Run(context.Background()))
+	assert.Equal(t, []string{
+		"POST /appStoreVersions",
+		"PATCH /appStoreVersions/v1/relationships/build",
+		"POST /appStoreReviewDetails",
+		"POST /idfaDeclarations",
+		"POST /appStoreVersionPhasedReleases",
+		"POST /appStoreVersionSubmissions",
+	}, server.changes())
+	assert.Equal(t, []ReleaseStep{
+		ReleaseStepVersion,
+		ReleaseStepBuild,
+		ReleaseStepReviewDetail,
+		ReleaseStepIDFADeclaration,
+		ReleaseStepPhasedRelease,
+		ReleaseStepSubmission,
+	}, steps)
+	assert.Equal(t, ReleaseState{
+		AppStoreVersionID: "v1",
+		ReviewDetailID:    "appStoreReviewDetails-1",
+		IDFADeclarationID: "idfaDeclarations-1",
+		PhasedReleaseID:   "appStoreVersionPhasedReleases-1",
+		SubmissionID:      "appStoreVersionSubmissions-1",
+		Completed:         steps,
+	}, workflow.State)
+
+	// Running again finds the version in review and considers the release done.
+	server.reset()
+
+	assert.NoError(t, NewReleaseWorkflow(client, testReleaseSpec()).Run(context.Background()))
+	assert.Empty(t, server.changes())
+}
+
+func TestReleaseWorkflowResume(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockReleaseServer(t)
+
+	spec := testReleaseSpec()
+	spec.Submit = false
+
+	first := NewReleaseWorkflow(client, spec)
+	assert.NoError(t, first.Run(context.Background()))
+
+	// A run without saved state updates the existing resources instead of creating new ones.
+	server.reset()
+
+	second := NewReleaseWorkflow(client, spec)
+	assert.NoError(t, second.Run(context.Background()))
+	assert.Equal(t, []string{
+		"PATCH /appStoreReviewDetails/appStoreReviewDetails-1",
+		"PATCH /idfaDeclarations/idfaDeclarations-1",
+	}, server.changes())
+	assert.Equal(t, first.State, second.State)
+
+	// A run with saved state only performs the remaining steps.
+	server.reset()
+	spec.Submit = true
+
+	third := NewReleaseWorkflow(client, spec)
+	third.State = second.State
+	assert.NoError(t, third.Run(context.Background()))
+	assert.Equal(t, []string{"POST /appStoreVersionSubmissions"}, server.changes())
+	assert.Equal(t, "appStoreVersionSubmissions-1", third.State.SubmissionID)
+}
+
+func TestReleaseWorkflowPreconditions(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockReleaseServer(t)
+
+	var precondition ErrReleasePrecondition
+
+	server.buildState = ""
+	workflow := NewReleaseWorkflow(client, testReleaseSpec())
+	err := workflow.Run(context.Background())
+	assert.EqualError(t, err, "release step build cannot run: build b1 has not finished processing")
+
+	server.buildState = BuildProcessingStateProcessing
+	err = workflow.Run(context.Background())
+	assert.True(t, errors.As(err, &precondition))
+	assert.Equal(t, ReleaseStepBuild, precondition.Step)
+	assert.Equal(t, []ReleaseStep{ReleaseStepVersion}, workflow.State.Completed)
+
+	server.buildState = BuildProcessingStateValid
+	server.usesEncryption = nil
+	err = workflow.Run(context.Background())
+	assert.EqualError(t, err, "release step build cannot run: build b1 is missing export compliance information")
+
+	server.versionState = AppStoreVersionStateReadyForSale
+	err = workflow.Run(context.Background())
+	assert.True(t, errors.As(err, &precondition))
+	assert.Equal(t, ReleaseStepVersion, precondition.Step)
+
+	spec := testReleaseSpec()
+	spec.ReviewDetail.ContactEmail = nil
+	server.versionState = AppStoreVersionStatePrepareForSubmission
+	server.usesEncryption = Bool(true)
+	err = NewReleaseWorkflow(client, spec).Run(context.Background())
+
+	var validationErr *ValidationError
+
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"POST /appStoreVersions", "PATCH /appStoreVersions/v1/relationships/build"}, server.changes())
+}
@@ -0,0 +1,265 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ErrBodyNotRewindable happens when a request with a body has to be retried, but the body
+// cannot be read a second time.
+var ErrBodyNotRewindable = errors.New("request body cannot be rewound for retry")
+
+const (
+	defaultRetryMaxAttempts     = 4
+	defaultRetryMaxElapsedTime  = time.Minute
+	defaultRetryInitialInterval = 500 * time.Millisecond
+	defaultRetryMaxInterval     = 10 * time.Second
+)
+
+// RetryPolicy configures how a Client retries requests that fail with a transient error.
+// Delays between attempts grow exponentially with jitter, and are extended to match the
+// Retry-After header of a 429 response.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made for a request, including the first.
+	// Zero means attempts are only bounded by MaxElapsedTime.
+	MaxAttempts int
+	// MaxElapsedTime is the maximum amount of time spent retrying a request. Zero means
+	// attempts are only bounded by MaxAttempts.
+	MaxElapsedTime time.Duration
+	// InitialInterval is the delay before the first retry.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between two attempts.
+	MaxInterval time.Duration
+	// Retryable classifies the outcome of an attempt as transient. Exactly one of resp and
+	// err is non-nil. It is called for requests of every method, including those that are not
+	// idempotent. If Retryable is nil, IsRetryable is used, and requests that are not idempotent
+	// are only retried after a 429 response or when they could not be sent at all.
+	Retryable func(resp *http.Response, err error) bool
+	// OnRetry, if set, is called before each retry.
+	OnRetry func(RetryAttempt)
+}
+
+// RetryAttempt describes a failed attempt that is about to be retried.
+type RetryAttempt struct {
+	// Request is the request being retried.
+	Request *http.Request
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+	// StatusCode is the status code of the failed attempt, or zero if it failed with a transport error.
+	StatusCode int
+	// Err describes why the attempt failed.
+	Err error
+	// Delay is how long the client will wait before the next attempt.
+	Delay time.Duration
+}
+
+// DefaultRetryPolicy returns the RetryPolicy used by clients created with NewClient. It makes up
+// to 4 attempts within one minute. Requests that are not idempotent, such as POST, PATCH and
+// DELETE, are only retried after a 429 response or when they could not be sent at all.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:     defaultRetryMaxAttempts,
+		MaxElapsedTime:  defaultRetryMaxElapsedTime,
+		InitialInterval: defaultRetryInitialInterval,
+		MaxInterval:     defaultRetryMaxInterval,
+	}
+}
+
+// SetRetryPolicy sets the RetryPolicy used by this client. Passing nil disables retries.
+func (c *Client) SetRetryPolicy(p *RetryPolicy) {
+	c.retryPolicy = p
+}
+
+// IsRetryable reports whether a response or transport error is transient. Responses with the
+// status codes 429, 500, 502, 503 and 504 are retryable, as are connection resets, unexpected
+// EOFs and timeouts. Context cancellation is never retryable.
+func IsRetryable(resp *http.Response, err error) bool {
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsIdempotent reports whether a request with the given method can be sent again without
+// changing its outcome. Only GET, HEAD and PUT requests are retried after a server error or a
+// transport error that may have happened once the request was sent.
+func IsIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *RetryPolicy) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if p == nil {
+		return false
+	}
+
+	if p.Retryable != nil {
+		return p.Retryable(resp, err)
+	}
+
+	if !IsRetryable(resp, err) {
+		return false
+	}
+
+	if IsIdempotent(req.Method) {
+		return true
+	}
+
+	if resp != nil {
+		return resp.StatusCode == http.StatusTooManyRequests
+	}
+
+	return isUnsent(err)
+}
+
+// isUnsent reports whether a transport error happened before the request was written, such as
+// failing to resolve or dial the host, so that the server cannot have acted on it.
+func isUnsent(err error) bool {
+	var (
+		opErr  *net.OpError
+		dnsErr *net.DNSError
+	)
+
+	return (errors.As(err, &opErr) && opErr.Op == "dial") || errors.As(err, &dnsErr)
+}
+
+func (p *RetryPolicy) backOff(ctx context.Context) *retryBackOff {
+	if p == nil {
+		return &retryBackOff{BackOff: &backoff.StopBackOff{}}
+	}
+
+	exp := backoff.NewExponentialBackOff()
+	exp.MaxElapsedTime = p.MaxElapsedTime
+
+	if p.InitialInterval > 0 {
+		exp.InitialInterval = p.InitialInterval
+	}
+
+	if p.MaxInterval > 0 {
+		exp.MaxInterval = p.MaxInterval
+	}
+
+	var b backoff.BackOff = exp
+	if p.MaxAttempts > 0 {
+		b = backoff.WithMaxRetries(b, uint64(p.MaxAttempts-1))
+	}
+
+	return &retryBackOff{BackOff: backoff.WithContext(b, ctx)}
+}
+
+func (p *RetryPolicy) notify(attempt RetryAttempt) {
+	if p != nil && p.OnRetry != nil {
+		p.OnRetry(attempt)
+	}
+}
+
+// retryBackOff extends the delay of the wrapped backoff to honor a Retry-After header.
+type retryBackOff struct {
+	backoff.BackOff
+
+	retryAfter time.Duration
+}
+
+func (b *retryBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next != backoff.Stop && b.retryAfter > next {
+		next = b.retryAfter
+	}
+
+	b.retryAfter = 0
+
+	return next
+}
+
+// retryableStatusError marks an attempt that produced a response with a retryable status code.
+type retryableStatusError struct {
+	StatusCode int
+}
+
+func (e retryableStatusError) Error() string {
+	return fmt.Sprintf("retryable status code %d", e.StatusCode)
+}
+
+// rewindBody resets the body of a request so it can be sent again.
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	if req.GetBody == nil {
+		return ErrBodyNotRewindable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+
+	req.Body = body
+
+	return nil
+}
+
+// retryAfterDelay returns the delay requested by the Retry-After header of a response.
+func retryAfterDelay(resp *http.Response) time.Duration {
+	now := time.Now()
+
+	until, ok := parseRetryAfter(resp.Header.Get(headerRetryAfter), now)
+	if !ok {
+		return 0
+	}
+
+	return until.Sub(now)
+}
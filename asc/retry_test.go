@@ -0,0 +1,277 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlakyServer(failures int32, status int, header http.Header) (*Client, *httptest.Server, *int32) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if atomic.AddInt32(&calls, 1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprintln(w, `{"errors":[{"code":"UNEXPECTED_ERROR","status":"500"}]}`)
+
+			return
+		}
+
+		fmt.Fprintf(w, `{"value":%q}`, string(body))
+	}))
+
+	base, _ := url.Parse(server.URL)
+	client := NewClient(server.Client())
+	client.baseURL = base
+	client.SetRetryPolicy(&RetryPolicy{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+	})
+
+	return client, server, &calls
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(nil)
+	assert.Equal(t, DefaultRetryPolicy(), client.retryPolicy)
+	client.SetRetryPolicy(nil)
+	assert.Nil(t, client.retryPolicy)
+}
+
+func TestRetryTransientStatus(t *testing.T) {
+	t.Parallel()
+
+	client, server, calls := newFlakyServer(2, http.StatusTooManyRequests, nil)
+	defer server.Close()
+
+	var attempts []RetryAttempt
+
+	client.retryPolicy.OnRetry = func(attempt RetryAttempt) {
+		attempts = append(attempts, attempt)
+	}
+
+	var unmarshaled mockPayload
+	resp, err := client.post(context.Background(), "test", newRequestBody(mockBody{"TEST"}), &unmarshaled)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+	assert.Equal(t, `{"data":{"Field":"TEST"}}`+"\n", unmarshaled.Value, "body should be rewound between attempts")
+	assert.Len(t, attempts, 2)
+	assert.Equal(t, 1, attempts[0].Attempt)
+	assert.Equal(t, http.StatusTooManyRequests, attempts[0].StatusCode)
+	assert.Error(t, attempts[0].Err)
+	assert.NotNil(t, attempts[0].Request)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	t.Parallel()
+
+	client, server, calls := newFlakyServer(5, http.StatusInternalServerError, nil)
+	defer server.Close()
+
+	resp, err := client.get(context.Background(), "test", nil, nil)
+
+	assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+	assert.NotNil(t, resp)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	var respErr *ErrorResponse
+
+	assert.True(t, errors.As(err, &respErr))
+}
+
+func TestRetryNonIdempotentServerError(t *testing.T) {
+	t.Parallel()
+
+	client, server, calls := newFlakyServer(1, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	_, err := client.post(context.Background(), "test", newRequestBody(mockBody{"TEST"}), nil)
+
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls), "a POST may have been applied before the server failed")
+}
+
+func TestRetryNotRetryableStatus(t *testing.T) {
+	t.Parallel()
+
+	client, server, calls := newFlakyServer(1, http.StatusConflict, nil)
+	defer server.Close()
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestRetryDisabled(t *testing.T) {
+	t.Parallel()
+
+	client, server, calls := newFlakyServer(1, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	client.SetRetryPolicy(nil)
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	client, server, _ := newFlakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}})
+	defer server.Close()
+
+	var delay time.Duration
+
+	client.retryPolicy.OnRetry = func(attempt RetryAttempt) {
+		delay = attempt.Delay
+	}
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Second, delay, float64(100*time.Millisecond))
+}
+
+func TestRetryCustomClassifier(t *testing.T) {
+	t.Parallel()
+
+	client, server, calls := newFlakyServer(1, http.StatusConflict, nil)
+	defer server.Close()
+
+	client.retryPolicy.Retryable = func(resp *http.Response, err error) bool {
+		return resp != nil && resp.StatusCode == http.StatusConflict
+	}
+
+	_, err := client.get(context.Background(), "test", nil, nil)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
+
+func TestRetryTransportError(t *testing.T) {
+	t.Parallel()
+
+	client, server, _ := newFlakyServer(0, http.StatusOK, nil)
+	server.Close()
+
+	var retries int
+
+	client.retryPolicy.OnRetry = func(attempt RetryAttempt) {
+		retries++
+	}
+
+	resp, err := client.get(context.Background(), "test", nil, nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Zero(t, retries, "connection refused is not transient")
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsRetryable(&http.Response{StatusCode: http.StatusTooManyRequests}, nil))
+	assert.True(t, IsRetryable(&http.Response{StatusCode: http.StatusGatewayTimeout}, nil))
+	assert.False(t, IsRetryable(&http.Response{StatusCode: http.StatusNotFound}, nil))
+	assert.False(t, IsRetryable(nil, nil))
+	assert.False(t, IsRetryable(nil, context.Canceled))
+	assert.False(t, IsRetryable(nil, fmt.Errorf("wrapped: %w", context.DeadlineExceeded)))
+	assert.True(t, IsRetryable(nil, fmt.Errorf("read: %w", syscall.ECONNRESET)))
+	assert.True(t, IsRetryable(nil, io.ErrUnexpectedEOF))
+	assert.True(t, IsRetryable(nil, &url.Error{Op: "Get", URL: "test", Err: mockTimeoutError{}}))
+	assert.False(t, IsRetryable(nil, errors.New("bad")))
+}
+
+func TestIsIdempotent(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsIdempotent(http.MethodGet))
+	assert.True(t, IsIdempotent(http.MethodHead))
+	assert.True(t, IsIdempotent(http.MethodPut))
+	assert.False(t, IsIdempotent(http.MethodPost))
+	assert.False(t, IsIdempotent(http.MethodPatch))
+	assert.False(t, IsIdempotent(http.MethodDelete))
+}
+
+func TestRetryableNonIdempotent(t *testing.T) {
+	t.Parallel()
+
+	policy := DefaultRetryPolicy()
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+
+	assert.True(t, policy.retryable(req, &http.Response{StatusCode: http.StatusTooManyRequests}, nil))
+	assert.False(t, policy.retryable(req, &http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.False(t, policy.retryable(req, nil, fmt.Errorf("read: %w", syscall.ECONNRESET)))
+	assert.True(t, policy.retryable(req, nil, &url.Error{Op: "Post", URL: "test", Err: &net.OpError{Op: "dial", Err: mockTimeoutError{}}}))
+	assert.False(t, policy.retryable(req, nil, &url.Error{Op: "Post", URL: "test", Err: &net.OpError{Op: "read", Err: mockTimeoutError{}}}))
+}
+
+func TestRewindBody(t *testing.T) {
+	t.Parallel()
+
+	req, _ := http.NewRequestWithContext(context.Background(), "GET", "test", nil)
+	assert.NoError(t, rewindBody(req))
+
+	req, _ = http.NewRequestWithContext(context.Background(), "POST", "test", strings.NewReader("body"))
+	assert.NoError(t, rewindBody(req))
+
+	req.GetBody = nil
+	assert.ErrorIs(t, rewindBody(req), ErrBodyNotRewindable)
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		return nil, errors.New("no")
+	}
+	assert.Error(t, rewindBody(req))
+}
+
+type mockTimeoutError struct{}
+
+func (mockTimeoutError) Error() string   { return "timeout" }
+func (mockTimeoutError) Timeout() bool   { return true }
+func (mockTimeoutError) Temporary() bool { return true }
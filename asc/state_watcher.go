@@ -0,0 +1,181 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"time"
+)
+
+const defaultWatchInterval = 30 * time.Second
+
+// WatchedState is a state that a StateWatcher can follow, such as AppStoreVersionState.
+type WatchedState interface {
+	~string
+	IsTerminal() bool
+}
+
+// StateTransition is a change of state observed by a StateWatcher.
+type StateTransition[S WatchedState] struct {
+	// From is the previously observed state, or empty for the first observation.
+	From S
+	To   S
+	Time time.Time
+}
+
+// StateFunc fetches the current state of a resource. It is typically a closure around one of
+// the Get methods on a service, such as AppsService.GetAppStoreVersion.
+type StateFunc[S WatchedState] func(ctx context.Context) (S, error)
+
+// StateWatcher polls the state of a resource and yields every transition it observes, starting
+// with the state it first finds. Because states are polled, short-lived states can be missed,
+// so consecutive transitions are not always allowed by CanTransitionTo.
+//
+//	watcher := client.Apps.WatchAppStoreVersion(versionID, time.Minute)
+//	for watcher.Next(ctx) {
+//		transition := watcher.Transition()
+//		log.Printf("%s -> %s", transition.From, transition.To)
+//	}
+//	if err := watcher.Err(); err != nil {
+//		return err
+//	}
+type StateWatcher[S WatchedState] struct {
+	interval time.Duration
+	fetch    StateFunc[S]
+
+	transition StateTransition[S]
+	polled     bool
+	done       bool
+	err        error
+}
+
+// NewStateWatcher creates a StateWatcher that calls fetch every interval. A zero interval polls
+// every 30 seconds.
+func NewStateWatcher[S WatchedState](interval time.Duration, fetch StateFunc[S]) *StateWatcher[S] {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	return &StateWatcher[S]{interval: interval, fetch: fetch}
+}
+
+// Next polls until the state changes, and returns true once it does. It returns false after a
+// terminal state has been yielded, when the context is done, or when an error occurred. Check
+// Err after Next returns false.
+func (w *StateWatcher[S]) Next(ctx context.Context) bool {
+	if w.done || w.err != nil {
+		return false
+	}
+
+	for {
+		if w.polled {
+			timer := time.NewTimer(w.interval)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				w.err = ctx.Err()
+
+				return false
+			case <-timer.C:
+			}
+		}
+
+		state, err := w.fetch(ctx)
+		if err != nil {
+			w.err = err
+
+			return false
+		}
+
+		first := !w.polled
+		w.polled = true
+
+		if state == "" || (!first && state == w.transition.To) {
+			continue
+		}
+
+		w.transition = StateTransition[S]{From: w.transition.To, To: state, Time: time.Now()}
+		w.done = state.IsTerminal()
+
+		return true
+	}
+}
+
+// Transition returns the current transition. It is only valid after a call to Next returns true.
+func (w *StateWatcher[S]) Transition() StateTransition[S] {
+	return w.transition
+}
+
+// State returns the most recently observed state, or an empty state if none has been observed.
+func (w *StateWatcher[S]) State() S {
+	return w.transition.To
+}
+
+// Err returns the error that stopped the watcher, including context cancellation.
+func (w *StateWatcher[S]) Err() error {
+	return w.err
+}
+
+// WatchAppStoreVersion creates a StateWatcher that follows the state of an App Store version
+// through review and release, until it reaches a terminal state such as READY_FOR_SALE.
+func (s *AppsService) WatchAppStoreVersion(id string, interval time.Duration) *StateWatcher[AppStoreVersionState] {
+	return NewStateWatcher(interval, func(ctx context.Context) (AppStoreVersionState, error) {
+		res, _, err := s.GetAppStoreVersion(ctx, id, &GetAppStoreVersionQuery{
+			FieldsAppStoreVersions: []string{"appStoreState"},
+		})
+		if err != nil || res.Data.Attributes == nil || res.Data.Attributes.AppStoreState == nil {
+			return "", err
+		}
+
+		return *res.Data.Attributes.AppStoreState, nil
+	})
+}
+
+// WatchBetaAppReviewSubmission creates a StateWatcher that follows the state of a beta app review
+// submission until App Review approves or rejects it.
+func (s *TestflightService) WatchBetaAppReviewSubmission(id string, interval time.Duration) *StateWatcher[BetaReviewState] {
+	return NewStateWatcher(interval, func(ctx context.Context) (BetaReviewState, error) {
+		res, _, err := s.GetBetaAppReviewSubmission(ctx, id, &GetBetaAppReviewSubmissionQuery{
+			FieldsBetaAppReviewSubmissions: []string{"betaReviewState"},
+		})
+		if err != nil || res.Data.Attributes == nil || res.Data.Attributes.BetaReviewState == nil {
+			return "", err
+		}
+
+		return *res.Data.Attributes.BetaReviewState, nil
+	})
+}
+
+// WatchBuildBetaDetail creates a StateWatcher that follows the external beta state of a build
+// through processing and beta review, until it is being tested, expired or failed to process.
+func (s *TestflightService) WatchBuildBetaDetail(id string, interval time.Duration) *StateWatcher[ExternalBetaState] {
+	return NewStateWatcher(interval, func(ctx context.Context) (ExternalBetaState, error) {
+		res, _, err := s.GetBuildBetaDetail(ctx, id, &GetBuildBetaDetailsQuery{
+			FieldsBuildBetaDetails: []string{"externalBuildState"},
+		})
+		if err != nil || res.Data.Attributes == nil || res.Data.Attributes.ExternalBuildState == nil {
+			return "", err
+		}
+
+		return *res.Data.Attributes.ExternalBuildState, nil
+	})
+}
@@ -0,0 +1,116 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockStateServer serves a resource at path whose state moves through the given states, one
+// per request, as the value of the given attribute.
+func newMockStateServer(t *testing.T, path string, attribute string, states ...string) (*Client, *routeServer) {
+	t.Helper()
+
+	var polls int
+
+	return newRouteServer(t, map[string]http.HandlerFunc{
+		"GET " + path: func(w http.ResponseWriter, r *http.Request) {
+			state := states[len(states)-1]
+			if polls < len(states) {
+				state = states[polls]
+			}
+
+			polls++
+
+			fmt.Fprintf(w, `{"data":{"id":"1","attributes":{%q:%q}}}`, attribute, state)
+		},
+	})
+}
+
+func TestWatchAppStoreVersion(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newMockStateServer(t, "/appStoreVersions/1", "appStoreState",
+		"WAITING_FOR_REVIEW", "WAITING_FOR_REVIEW", "IN_REVIEW", "PENDING_DEVELOPER_RELEASE", "READY_FOR_SALE")
+
+	watcher := client.Apps.WatchAppStoreVersion("1", time.Millisecond)
+
+	var transitions []string
+	for watcher.Next(context.Background()) {
+		transition := watcher.Transition()
+		transitions = append(transitions, fmt.Sprintf("%s->%s", transition.From, transition.To))
+	}
+
+	assert.NoError(t, watcher.Err())
+	assert.Equal(t, []string{
+		"->WAITING_FOR_REVIEW",
+		"WAITING_FOR_REVIEW->IN_REVIEW",
+		"IN_REVIEW->PENDING_DEVELOPER_RELEASE",
+		"PENDING_DEVELOPER_RELEASE->READY_FOR_SALE",
+	}, transitions)
+	assert.Equal(t, AppStoreVersionStateReadyForSale, watcher.State())
+	assert.False(t, watcher.Next(context.Background()))
+}
+
+func TestWatchBetaAppReviewSubmission(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newMockStateServer(t, "/betaAppReviewSubmissions/1", "betaReviewState", "IN_REVIEW")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	watcher := client.TestFlight.WatchBetaAppReviewSubmission("1", time.Millisecond)
+	assert.True(t, watcher.Next(ctx))
+	assert.Equal(t, BetaReviewStateInReview, watcher.State())
+	assert.False(t, watcher.Next(ctx))
+	assert.ErrorIs(t, watcher.Err(), context.DeadlineExceeded)
+}
+
+func TestWatchBuildBetaDetail(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockStateServer(t, "/buildBetaDetails/1", "externalBuildState", "IN_BETA_REVIEW", "IN_BETA_TESTING")
+
+	watcher := client.TestFlight.WatchBuildBetaDetail("1", time.Millisecond)
+	assert.True(t, watcher.Next(context.Background()))
+	assert.True(t, watcher.Next(context.Background()))
+	assert.Equal(t, StateTransition[ExternalBetaState]{
+		From: ExternalBetaStateInReview,
+		To:   ExternalBetaStateInTesting,
+		Time: watcher.Transition().Time,
+	}, watcher.Transition())
+	assert.False(t, watcher.Next(context.Background()))
+	assert.NoError(t, watcher.Err())
+
+	server.handle("GET /buildBetaDetails/2", notFound)
+
+	watcher = client.TestFlight.WatchBuildBetaDetail("2", time.Millisecond)
+	assert.False(t, watcher.Next(context.Background()))
+	assert.True(t, errors.Is(watcher.Err(), ErrNotFound))
+}
@@ -0,0 +1,283 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+// appStoreVersionTransitions lists the states an App Store version can move to from each state.
+var appStoreVersionTransitions = map[AppStoreVersionState][]AppStoreVersionState{
+	AppStoreVersionStatePrepareForSubmission: {
+		AppStoreVersionStateWaitingForExportCompliance,
+		AppStoreVersionStateWaitingForReview,
+	},
+	AppStoreVersionStateWaitingForExportCompliance: {
+		AppStoreVersionStateWaitingForReview,
+		AppStoreVersionStateDeveloperRejected,
+		AppStoreVersionStateInvalidBinary,
+	},
+	AppStoreVersionStateWaitingForReview: {
+		AppStoreVersionStateInReview,
+		AppStoreVersionStateDeveloperRejected,
+		AppStoreVersionStateInvalidBinary,
+	},
+	AppStoreVersionStateInReview: {
+		AppStoreVersionStatePendingContract,
+		AppStoreVersionStatePendingAppleRelease,
+		AppStoreVersionStatePendingDeveloperRelease,
+		AppStoreVersionStateProcessingForAppStore,
+		AppStoreVersionStateRejected,
+		AppStoreVersionStateMetadataRejected,
+		AppStoreVersionStateDeveloperRejected,
+	},
+	AppStoreVersionStatePendingContract: {
+		AppStoreVersionStatePendingAppleRelease,
+		AppStoreVersionStatePendingDeveloperRelease,
+		AppStoreVersionStateProcessingForAppStore,
+		AppStoreVersionStateDeveloperRejected,
+	},
+	AppStoreVersionStatePendingAppleRelease: {
+		AppStoreVersionStateProcessingForAppStore,
+		AppStoreVersionStateReadyForSale,
+		AppStoreVersionStateDeveloperRejected,
+	},
+	AppStoreVersionStatePendingDeveloperRelease: {
+		AppStoreVersionStateProcessingForAppStore,
+		AppStoreVersionStateReadyForSale,
+		AppStoreVersionStateDeveloperRejected,
+	},
+	AppStoreVersionStateProcessingForAppStore: {
+		AppStoreVersionStatePreorderReadyForSale,
+		AppStoreVersionStateReadyForSale,
+	},
+	AppStoreVersionStatePreorderReadyForSale: {
+		AppStoreVersionStateReadyForSale,
+		AppStoreVersionStateDeveloperRemovedFromSale,
+		AppStoreVersionStateRemovedFromSale,
+	},
+	AppStoreVersionStateReadyForSale: {
+		AppStoreVersionStateDeveloperRemovedFromSale,
+		AppStoreVersionStateRemovedFromSale,
+		AppStoreVersionStateReplacedWithNewVersion,
+	},
+	AppStoreVersionStateDeveloperRemovedFromSale: {
+		AppStoreVersionStateReadyForSale,
+		AppStoreVersionStateReplacedWithNewVersion,
+	},
+	AppStoreVersionStateRemovedFromSale: {
+		AppStoreVersionStateReadyForSale,
+		AppStoreVersionStateReplacedWithNewVersion,
+	},
+	AppStoreVersionStateRejected: {
+		AppStoreVersionStatePrepareForSubmission,
+		AppStoreVersionStateWaitingForReview,
+	},
+	AppStoreVersionStateMetadataRejected: {
+		AppStoreVersionStatePrepareForSubmission,
+		AppStoreVersionStateWaitingForReview,
+	},
+	AppStoreVersionStateDeveloperRejected: {
+		AppStoreVersionStatePrepareForSubmission,
+		AppStoreVersionStateWaitingForReview,
+	},
+	AppStoreVersionStateInvalidBinary: {
+		AppStoreVersionStatePrepareForSubmission,
+		AppStoreVersionStateWaitingForReview,
+	},
+	AppStoreVersionStateReplacedWithNewVersion: {},
+}
+
+// Transitions returns the states an App Store version in this state can move to. It returns nil
+// for an unknown state.
+func (s AppStoreVersionState) Transitions() []AppStoreVersionState {
+	return appStoreVersionTransitions[s]
+}
+
+// CanTransitionTo reports whether an App Store version in this state can move directly to next.
+func (s AppStoreVersionState) CanTransitionTo(next AppStoreVersionState) bool {
+	return contains(appStoreVersionTransitions[s], next)
+}
+
+// IsTerminal reports whether an App Store version in this state has finished going through review
+// and release. It can still change state, such as when it is removed from sale, but not without
+// a new action from the developer or Apple.
+func (s AppStoreVersionState) IsTerminal() bool {
+	switch s {
+	case AppStoreVersionStateReadyForSale,
+		AppStoreVersionStateDeveloperRemovedFromSale,
+		AppStoreVersionStateRemovedFromSale,
+		AppStoreVersionStateReplacedWithNewVersion:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsActionable reports whether an App Store version in this state waits for the developer, such
+// as to submit it, to release it or to fix a rejection.
+func (s AppStoreVersionState) IsActionable() bool {
+	switch s {
+	case AppStoreVersionStatePrepareForSubmission,
+		AppStoreVersionStateWaitingForExportCompliance,
+		AppStoreVersionStatePendingContract,
+		AppStoreVersionStatePendingDeveloperRelease,
+		AppStoreVersionStateRejected,
+		AppStoreVersionStateMetadataRejected,
+		AppStoreVersionStateDeveloperRejected,
+		AppStoreVersionStateInvalidBinary:
+		return true
+	default:
+		return false
+	}
+}
+
+// betaReviewTransitions lists the states a beta app review submission can move to from each state.
+var betaReviewTransitions = map[BetaReviewState][]BetaReviewState{
+	BetaReviewStateWaitingForReview: {BetaReviewStateInReview},
+	BetaReviewStateInReview:         {BetaReviewStateApproved, BetaReviewStateRejected},
+	BetaReviewStateApproved:         {},
+	BetaReviewStateRejected:         {},
+}
+
+// Transitions returns the states a beta app review submission in this state can move to. It
+// returns nil for an unknown state.
+func (s BetaReviewState) Transitions() []BetaReviewState {
+	return betaReviewTransitions[s]
+}
+
+// CanTransitionTo reports whether a beta app review submission in this state can move directly
+// to next.
+func (s BetaReviewState) CanTransitionTo(next BetaReviewState) bool {
+	return contains(betaReviewTransitions[s], next)
+}
+
+// IsTerminal reports whether App Review has decided on a beta app review submission in this state.
+func (s BetaReviewState) IsTerminal() bool {
+	return s == BetaReviewStateApproved || s == BetaReviewStateRejected
+}
+
+// IsActionable reports whether a beta app review submission in this state waits for the developer
+// to fix a rejection and submit the build again.
+func (s BetaReviewState) IsActionable() bool {
+	return s == BetaReviewStateRejected
+}
+
+// externalBetaTransitions lists the states a build can move to from each state of its external
+// beta testing.
+var externalBetaTransitions = map[ExternalBetaState][]ExternalBetaState{
+	ExternalBetaStateProcessing: {
+		ExternalBetaStateProcessingException,
+		ExternalBetaStateMissingExportCompliance,
+		ExternalBetaStateInExportComplianceReview,
+		ExternalBetaStateReadyForBetaSubmission,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateProcessingException: {
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateMissingExportCompliance: {
+		ExternalBetaStateInExportComplianceReview,
+		ExternalBetaStateReadyForBetaSubmission,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateInExportComplianceReview: {
+		ExternalBetaStateReadyForBetaSubmission,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateReadyForBetaSubmission: {
+		ExternalBetaStateWaitingForBetaReview,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateWaitingForBetaReview: {
+		ExternalBetaStateInReview,
+		ExternalBetaStateReadyForBetaSubmission,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateInReview: {
+		ExternalBetaStateApproved,
+		ExternalBetaStateRejected,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateApproved: {
+		ExternalBetaStateReadyForBetaTesting,
+		ExternalBetaStateInTesting,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateRejected: {
+		ExternalBetaStateWaitingForBetaReview,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateReadyForBetaTesting: {
+		ExternalBetaStateInTesting,
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateInTesting: {
+		ExternalBetaStateExpired,
+	},
+	ExternalBetaStateExpired: {},
+}
+
+// Transitions returns the states a build in this external beta state can move to. It returns
+// nil for an unknown state.
+func (s ExternalBetaState) Transitions() []ExternalBetaState {
+	return externalBetaTransitions[s]
+}
+
+// CanTransitionTo reports whether a build in this external beta state can move directly to next.
+func (s ExternalBetaState) CanTransitionTo(next ExternalBetaState) bool {
+	return contains(externalBetaTransitions[s], next)
+}
+
+// IsTerminal reports whether a build in this external beta state has finished going through beta
+// review, either because it is being tested, it failed to process or it expired.
+func (s ExternalBetaState) IsTerminal() bool {
+	switch s {
+	case ExternalBetaStateInTesting,
+		ExternalBetaStateProcessingException,
+		ExternalBetaStateExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsActionable reports whether a build in this external beta state waits for the developer, such
+// as to provide export compliance information, to submit it for beta review or to add it to a
+// beta group.
+func (s ExternalBetaState) IsActionable() bool {
+	switch s {
+	case ExternalBetaStateMissingExportCompliance,
+		ExternalBetaStateReadyForBetaSubmission,
+		ExternalBetaStateReadyForBetaTesting,
+		ExternalBetaStateRejected,
+		ExternalBetaStateProcessingException:
+		return true
+	default:
+		return false
+	}
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppStoreVersionStateTransitions(t *testing.T) {
+	t.Parallel()
+
+	states := []AppStoreVersionState{
+		AppStoreVersionStateDeveloperRejected,
+		AppStoreVersionStateDeveloperRemovedFromSale,
+		AppStoreVersionStateInvalidBinary,
+		AppStoreVersionStateInReview,
+		AppStoreVersionStateMetadataRejected,
+		AppStoreVersionStatePendingAppleRelease,
+		AppStoreVersionStatePendingContract,
+		AppStoreVersionStatePendingDeveloperRelease,
+		AppStoreVersionStatePreorderReadyForSale,
+		AppStoreVersionStatePrepareForSubmission,
+		AppStoreVersionStateProcessingForAppStore,
+		AppStoreVersionStateReadyForSale,
+		AppStoreVersionStateRejected,
+		AppStoreVersionStateRemovedFromSale,
+		AppStoreVersionStateReplacedWithNewVersion,
+		AppStoreVersionStateWaitingForExportCompliance,
+		AppStoreVersionStateWaitingForReview,
+	}
+
+	assert.Len(t, appStoreVersionTransitions, len(states))
+
+	for _, state := range states {
+		assert.NotNil(t, state.Transitions(), state)
+		assert.False(t, state.IsTerminal() && state.IsActionable(), state)
+
+		for _, next := range state.Transitions() {
+			assert.Contains(t, states, next)
+		}
+	}
+
+	assert.True(t, AppStoreVersionStateWaitingForReview.CanTransitionTo(AppStoreVersionStateInReview))
+	assert.True(t, AppStoreVersionStateInReview.CanTransitionTo(AppStoreVersionStatePendingDeveloperRelease))
+	assert.False(t, AppStoreVersionStatePrepareForSubmission.CanTransitionTo(AppStoreVersionStateReadyForSale))
+	assert.False(t, AppStoreVersionState("UNKNOWN").CanTransitionTo(AppStoreVersionStateInReview))
+	assert.Nil(t, AppStoreVersionState("UNKNOWN").Transitions())
+	assert.True(t, AppStoreVersionStateReadyForSale.IsTerminal())
+	assert.True(t, AppStoreVersionStatePendingDeveloperRelease.IsActionable())
+	assert.False(t, AppStoreVersionStateInReview.IsActionable())
+}
+
+func TestBetaReviewStateTransitions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []BetaReviewState{BetaReviewStateInReview}, BetaReviewStateWaitingForReview.Transitions())
+	assert.True(t, BetaReviewStateInReview.CanTransitionTo(BetaReviewStateRejected))
+	assert.False(t, BetaReviewStateApproved.CanTransitionTo(BetaReviewStateInReview))
+	assert.True(t, BetaReviewStateApproved.IsTerminal())
+	assert.False(t, BetaReviewStateApproved.IsActionable())
+	assert.True(t, BetaReviewStateRejected.IsActionable())
+	assert.False(t, BetaReviewStateInReview.IsTerminal())
+}
+
+func TestExternalBetaStateTransitions(t *testing.T) {
+	t.Parallel()
+
+	for state, transitions := range externalBetaTransitions {
+		for _, next := range transitions {
+			assert.Contains(t, externalBetaTransitions, next, state)
+		}
+
+		if state != ExternalBetaStateExpired {
+			assert.True(t, state.CanTransitionTo(ExternalBetaStateExpired), state)
+		}
+	}
+
+	assert.Len(t, externalBetaTransitions, 12)
+	assert.True(t, ExternalBetaStateWaitingForBetaReview.CanTransitionTo(ExternalBetaStateInReview))
+	assert.False(t, ExternalBetaStateInTesting.CanTransitionTo(ExternalBetaStateInReview))
+	assert.True(t, ExternalBetaStateInTesting.IsTerminal())
+	assert.True(t, ExternalBetaStateMissingExportCompliance.IsActionable())
+	assert.False(t, ExternalBetaStateInReview.IsActionable())
+}
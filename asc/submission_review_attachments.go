@@ -23,6 +23,7 @@ package asc
 import (
 	"context"
 	"fmt"
+	"io"
 )
 
 // AppStoreReviewAttachment defines model for AppStoreReviewAttachment.
@@ -209,3 +210,60 @@ func (s *SubmissionService) DeleteAttachment(ctx context.Context, id string) (*R
 
 	return s.client.delete(ctx, url, nil)
 }
+
+// UploadAttachment uploads a file as a new attachment for App Review. It works like
+// AppsService.UploadScreenshot.
+//
+// https://developer.apple.com/documentation/appstoreconnectapi/uploading_assets_to_app_store_connect
+func (s *SubmissionService) UploadAttachment(ctx context.Context, appStoreReviewDetailID string, fileName string, file io.ReaderAt, fileSize int64) (*AppStoreReviewAttachment, error) {
+	return uploadAsset(ctx, s.client, s.attachmentAsset(appStoreReviewDetailID, fileName, fileSize), file, fileSize)
+}
+
+// attachmentAsset describes how UploadAttachment reserves, commits, reads and deletes an App
+// Review attachment.
+func (s *SubmissionService) attachmentAsset(appStoreReviewDetailID string, fileName string, fileSize int64) assetKind[AppStoreReviewAttachment] {
+	return assetKind[AppStoreReviewAttachment]{
+		reserve: func(ctx context.Context) (AppStoreReviewAttachment, error) {
+			res, _, err := s.CreateAttachment(ctx, fileName, fileSize, appStoreReviewDetailID)
+			if err != nil {
+				return AppStoreReviewAttachment{}, err
+			}
+
+			return res.Data, nil
+		},
+		commit: func(ctx context.Context, id string, checksum string) error {
+			_, _, err := s.CommitAttachment(ctx, id, Bool(true), &checksum)
+
+			return err
+		},
+		get: func(ctx context.Context, id string) (AppStoreReviewAttachment, error) {
+			res, _, err := s.GetAttachment(ctx, id, nil)
+			if err != nil {
+				return AppStoreReviewAttachment{}, err
+			}
+
+			return res.Data, nil
+		},
+		remove: s.DeleteAttachment,
+	}
+}
+
+func (a AppStoreReviewAttachment) assetID() string {
+	return a.ID
+}
+
+func (a AppStoreReviewAttachment) uploadOperations() []UploadOperation {
+	if a.Attributes == nil {
+		return nil
+	}
+
+	return a.Attributes.UploadOperations
+}
+
+func (a AppStoreReviewAttachment) deliveryState() *AppMediaAssetState {
+	if a.Attributes == nil {
+		return nil
+	}
+
+	return a.Attributes.AssetDeliveryState
+}
@@ -22,7 +22,11 @@ package asc // nolint: dupl
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetAttachment(t *testing.T) {
@@ -64,3 +68,29 @@ func TestDeleteAttachment(t *testing.T) {
 		return client.Submission.DeleteAttachment(ctx, "10")
 	})
 }
+
+func TestUploadAttachment(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "appStoreReviewAttachments", AppMediaAssetStateComplete)
+
+	attachment, err := client.Submission.UploadAttachment(context.Background(), "10", "notes.pdf", strings.NewReader("contents"), 8)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", attachment.ID)
+	assert.Equal(t, "contents", string(server.uploaded))
+	assert.Equal(t, "98bf7d8c15784f0a3d63204441e1e2aa", server.commitAttributes()["sourceFileChecksum"])
+	assert.False(t, server.deleted)
+}
+
+func TestUploadAttachmentReservationFailed(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockAssetServer(t, "appStoreReviewAttachments", AppMediaAssetStateComplete)
+	server.handle("POST /appStoreReviewAttachments", notFound)
+
+	_, err := client.Submission.UploadAttachment(context.Background(), "10", "notes.pdf", strings.NewReader("contents"), 8)
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, server.deleted)
+}
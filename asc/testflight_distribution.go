@@ -0,0 +1,438 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDistributionPrecondition happens when DistributeBuild cannot continue because App Store
+// Connect is not in the state the next step requires, such as a build that is still processing.
+type ErrDistributionPrecondition struct {
+	Step   DistributionStep
+	Reason string
+}
+
+func (e ErrDistributionPrecondition) Error() string {
+	return fmt.Sprintf("distribution step %s cannot run: %s", e.Step, e.Reason)
+}
+
+// DistributionStep is a step of DistributeBuild.
+type DistributionStep string
+
+// Steps of DistributeBuild, in the order they run.
+const (
+	// DistributionStepBuild checks that the build has finished processing and has not expired.
+	// It only fails a distribution, and is not listed in the DistributionReport.
+	DistributionStepBuild DistributionStep = "build"
+	// DistributionStepEncryption provides the export compliance information of the build.
+	DistributionStepEncryption DistributionStep = "encryption"
+	// DistributionStepWhatToTest creates or updates the "What to Test" notes of each locale.
+	DistributionStepWhatToTest DistributionStep = "whatToTest"
+	// DistributionStepBetaGroups gives the beta groups access to the build.
+	DistributionStepBetaGroups DistributionStep = "betaGroups"
+	// DistributionStepReview submits the build to beta app review.
+	DistributionStepReview DistributionStep = "review"
+	// DistributionStepNotification notifies testers that the build is available.
+	DistributionStepNotification DistributionStep = "notification"
+)
+
+// DistributionSpec describes how DistributeBuild distributes a build.
+type DistributionSpec struct {
+	// BuildID is the build to distribute. It must have finished processing.
+	BuildID string
+	// BetaGroups are the names of the beta groups of the app that get access to the build.
+	BetaGroups []string
+	// WhatToTest are the "What to Test" notes of the build, keyed by locale, such as "en-US".
+	WhatToTest map[string]string
+	// UsesNonExemptEncryption is the export compliance information given for the build if it has
+	// none yet. If nil, the build must already have it.
+	UsesNonExemptEncryption *bool
+	// Notify notifies testers that the build is available, unless App Store Connect does it
+	// automatically. It is only done once the build can be tested by every group.
+	Notify bool
+}
+
+// DistributionStepResult is the outcome of one step of DistributeBuild.
+type DistributionStepResult struct {
+	Step DistributionStep
+	// Skipped reports whether the step had nothing to do, because an earlier run already did it
+	// or because it does not apply to the build.
+	Skipped bool
+	Detail  string
+}
+
+// DistributionReport describes what DistributeBuild did.
+type DistributionReport struct {
+	Steps []DistributionStepResult
+	// ExternalBetaState is the external beta state of the build after the last step, or empty
+	// if the build was only distributed to internal groups.
+	ExternalBetaState ExternalBetaState
+}
+
+// String formats the report with one line per step.
+func (r *DistributionReport) String() string {
+	var b strings.Builder
+
+	for _, step := range r.Steps {
+		status := "done"
+		if step.Skipped {
+			status = "skipped"
+		}
+
+		fmt.Fprintf(&b, "%s: %s", step.Step, status)
+
+		if step.Detail != "" {
+			fmt.Fprintf(&b, " (%s)", step.Detail)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// distribution holds the state shared by the steps of DistributeBuild.
+type distribution struct {
+	spec     DistributionSpec
+	build    Build
+	appID    string
+	external bool
+	report   *DistributionReport
+}
+
+func (d *distribution) done(step DistributionStep, skipped bool, format string, args ...interface{}) {
+	d.report.Steps = append(d.report.Steps, DistributionStepResult{
+		Step:    step,
+		Skipped: skipped,
+		Detail:  fmt.Sprintf(format, args...),
+	})
+}
+
+// DistributeBuild distributes a build to TestFlight beta groups. It provides the export
+// compliance information of the build, sets its "What to Test" notes, gives the beta groups
+// access to it, submits it to beta app review if any group has external testers and notifies
+// testers once it can be tested.
+//
+// Every step first checks whether its work has already been done, so running DistributeBuild
+// again, such as after a failure or once beta app review approves the build, only performs the
+// remaining steps. It returns an ErrDistributionPrecondition if App Store Connect is not ready
+// for a step, along with the report of the steps that ran.
+func (s *TestflightService) DistributeBuild(ctx context.Context, spec DistributionSpec) (*DistributionReport, error) {
+	d := &distribution{spec: spec, report: new(DistributionReport)}
+
+	if err := s.distributionBuild(ctx, d); err != nil {
+		return d.report, err
+	}
+
+	steps := []func(ctx context.Context, d *distribution) error{
+		s.distributionEncryption,
+		s.distributionWhatToTest,
+		s.distributionBetaGroups,
+		s.distributionReview,
+		s.distributionNotification,
+	}
+
+	for _, step := range steps {
+		if err := step(ctx, d); err != nil {
+			return d.report, err
+		}
+	}
+
+	return d.report, nil
+}
+
+func (s *TestflightService) distributionBuild(ctx context.Context, d *distribution) error {
+	res, _, err := s.client.Builds.GetBuild(ctx, d.spec.BuildID, nil)
+	if err != nil {
+		return err
+	}
+
+	d.build = res.Data
+
+	attributes := d.build.Attributes
+	if attributes != nil && attributes.Expired != nil && *attributes.Expired {
+		return ErrDistributionPrecondition{
+			Step:   DistributionStepBuild,
+			Reason: fmt.Sprintf("build %s has expired", d.spec.BuildID),
+		}
+	}
+
+	if attributes == nil || attributes.ProcessingState == nil || *attributes.ProcessingState != BuildProcessingStateValid {
+		return ErrDistributionPrecondition{
+			Step:   DistributionStepBuild,
+			Reason: fmt.Sprintf("build %s has not finished processing", d.spec.BuildID),
+		}
+	}
+
+	app, _, err := s.client.Builds.GetAppForBuild(ctx, d.spec.BuildID, &GetAppForBuildQuery{FieldsApps: []string{"bundleId"}})
+	if err != nil {
+		return err
+	}
+
+	d.appID = app.Data.ID
+
+	return nil
+}
+
+func (s *TestflightService) distributionEncryption(ctx context.Context, d *distribution) error {
+	if d.build.Attributes != nil && d.build.Attributes.UsesNonExemptEncryption != nil {
+		d.done(DistributionStepEncryption, true, "")
+
+		return nil
+	}
+
+	if d.spec.UsesNonExemptEncryption == nil {
+		return ErrDistributionPrecondition{
+			Step:   DistributionStepEncryption,
+			Reason: fmt.Sprintf("build %s is missing export compliance information", d.spec.BuildID),
+		}
+	}
+
+	if _, _, err := s.client.Builds.UpdateBuild(ctx, d.spec.BuildID, nil, d.spec.UsesNonExemptEncryption, nil); err != nil {
+		return err
+	}
+
+	d.done(DistributionStepEncryption, false, "")
+
+	return nil
+}
+
+func (s *TestflightService) distributionWhatToTest(ctx context.Context, d *distribution) error {
+	if len(d.spec.WhatToTest) == 0 {
+		d.done(DistributionStepWhatToTest, true, "")
+
+		return nil
+	}
+
+	res, _, err := s.ListBetaBuildLocalizationsForBuild(ctx, d.spec.BuildID, &ListBetaBuildLocalizationsForBuildQuery{Limit: 200})
+	if err != nil {
+		return err
+	}
+
+	live := make(map[string]BetaBuildLocalization, len(res.Data))
+
+	for _, localization := range res.Data {
+		if localization.Attributes != nil && localization.Attributes.Locale != nil {
+			live[*localization.Attributes.Locale] = localization
+		}
+	}
+
+	locales := make([]string, 0, len(d.spec.WhatToTest))
+	for locale := range d.spec.WhatToTest {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+
+	var changed []string
+
+	for _, locale := range locales {
+		notes := d.spec.WhatToTest[locale]
+
+		localization, ok := live[locale]
+		if !ok {
+			if _, _, err := s.CreateBetaBuildLocalization(ctx, locale, &notes, d.spec.BuildID); err != nil {
+				return err
+			}
+
+			changed = append(changed, locale)
+
+			continue
+		}
+
+		if localization.Attributes.WhatsNew != nil && *localization.Attributes.WhatsNew == notes {
+			continue
+		}
+
+		if _, _, err := s.UpdateBetaBuildLocalization(ctx, localization.ID, &notes); err != nil {
+			return err
+		}
+
+		changed = append(changed, locale)
+	}
+
+	d.done(DistributionStepWhatToTest, len(changed) == 0, strings.Join(changed, ", "))
+
+	return nil
+}
+
+func (s *TestflightService) distributionBetaGroups(ctx context.Context, d *distribution) error {
+	res, _, err := NewPager[BetaGroup](s.client, func(ctx context.Context) (*BetaGroupsResponse, *Response, error) {
+		return s.ListBetaGroupsForApp(ctx, d.appID, &ListBetaGroupsForAppQuery{
+			FieldsBetaGroups: []string{"name", "isInternalGroup"},
+			Limit:            200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string]BetaGroup, len(res.Data))
+
+	for _, group := range res.Data {
+		if group.Attributes != nil && group.Attributes.Name != nil {
+			groups[*group.Attributes.Name] = group
+		}
+	}
+
+	var (
+		missingIDs   []string
+		missingNames []string
+	)
+
+	for _, name := range d.spec.BetaGroups {
+		group, ok := groups[name]
+		if !ok {
+			return ErrUnknownBetaGroup{Name: name}
+		}
+
+		if group.Attributes.IsInternalGroup == nil || !*group.Attributes.IsInternalGroup {
+			d.external = true
+		}
+
+		builds, _, err := NewPager[RelationshipData](s.client, func(ctx context.Context) (*BetaGroupBuildsLinkagesResponse, *Response, error) {
+			return s.ListBuildIDsForBetaGroup(ctx, group.ID, &ListBuildIDsForBetaGroupQuery{Limit: 200})
+		}).All(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !containsRelationship(builds.Data, d.spec.BuildID) {
+			missingIDs = append(missingIDs, group.ID)
+			missingNames = append(missingNames, name)
+		}
+	}
+
+	if len(missingIDs) == 0 {
+		d.done(DistributionStepBetaGroups, true, "")
+
+		return nil
+	}
+
+	if _, err := s.client.Builds.CreateAccessForBetaGroupsToBuild(ctx, d.spec.BuildID, missingIDs); err != nil {
+		return err
+	}
+
+	d.done(DistributionStepBetaGroups, false, strings.Join(missingNames, ", "))
+
+	return nil
+}
+
+func (s *TestflightService) distributionReview(ctx context.Context, d *distribution) error {
+	if !d.external {
+		d.done(DistributionStepReview, true, "no external beta groups")
+
+		return nil
+	}
+
+	detail, _, err := s.GetBuildBetaDetailForBuild(ctx, d.spec.BuildID, &GetBuildBetaDetailForBuildQuery{
+		FieldsBuildBetaDetails: []string{"externalBuildState"},
+	})
+	if err != nil {
+		return err
+	}
+
+	var state ExternalBetaState
+	if detail.Data.Attributes != nil && detail.Data.Attributes.ExternalBuildState != nil {
+		state = *detail.Data.Attributes.ExternalBuildState
+	}
+
+	d.report.ExternalBetaState = state
+
+	switch state {
+	case ExternalBetaStateReadyForBetaSubmission:
+		if _, _, err := s.CreateBetaAppReviewSubmission(ctx, d.spec.BuildID); err != nil {
+			return err
+		}
+
+		d.report.ExternalBetaState = ExternalBetaStateWaitingForBetaReview
+		d.done(DistributionStepReview, false, "")
+	case ExternalBetaStateWaitingForBetaReview,
+		ExternalBetaStateInReview,
+		ExternalBetaStateApproved,
+		ExternalBetaStateReadyForBetaTesting,
+		ExternalBetaStateInTesting:
+		d.done(DistributionStepReview, true, string(state))
+	default:
+		return ErrDistributionPrecondition{
+			Step:   DistributionStepReview,
+			Reason: fmt.Sprintf("build %s is in external beta state %s", d.spec.BuildID, state),
+		}
+	}
+
+	return nil
+}
+
+func (s *TestflightService) distributionNotification(ctx context.Context, d *distribution) error {
+	if !d.spec.Notify {
+		d.done(DistributionStepNotification, true, "")
+
+		return nil
+	}
+
+	if d.external && d.report.ExternalBetaState != ExternalBetaStateReadyForBetaTesting && d.report.ExternalBetaState != ExternalBetaStateInTesting {
+		d.done(DistributionStepNotification, true, "build is %s", d.report.ExternalBetaState)
+
+		return nil
+	}
+
+	detail, _, err := s.GetBuildBetaDetailForBuild(ctx, d.spec.BuildID, &GetBuildBetaDetailForBuildQuery{
+		FieldsBuildBetaDetails: []string{"autoNotifyEnabled"},
+	})
+	if err != nil {
+		return err
+	}
+
+	if attributes := detail.Data.Attributes; attributes != nil && attributes.AutoNotifyEnabled != nil && *attributes.AutoNotifyEnabled {
+		d.done(DistributionStepNotification, true, "testers are notified automatically")
+
+		return nil
+	}
+
+	// App Store Connect rejects a second notification for the same build with a conflict.
+	_, _, err = s.CreateAvailableBuildNotification(ctx, d.spec.BuildID)
+	if errors.Is(err, ErrConflict) {
+		d.done(DistributionStepNotification, true, "testers were already notified")
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	d.done(DistributionStepNotification, false, "")
+
+	return nil
+}
+
+func containsRelationship(relationships []RelationshipData, id string) bool {
+	for _, relationship := range relationships {
+		if relationship.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDistribution is the state of build "b1" of app "a1", which has the internal beta group
+// "Internal" (g1), already testing the build, and the external beta group "External" (g2).
+type mockDistribution struct {
+	noAttributes       bool
+	encryption         string
+	whatsNew           string
+	external           bool
+	notified           bool
+	externalBuildState ExternalBetaState
+}
+
+func newMockDistributionServer(t *testing.T, state *mockDistribution) (*Client, *routeServer) {
+	t.Helper()
+
+	return newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /builds/b1": func(w http.ResponseWriter, r *http.Request) {
+			if state.noAttributes {
+				fmt.Fprint(w, `{"data":{"id":"b1","type":"builds"}}`)
+
+				return
+			}
+
+			fmt.Fprintf(w, `{"data":{"id":"b1","type":"builds","attributes":{"processingState":"VALID"%s}}}`, state.encryption)
+		},
+		"PATCH /builds/b1": func(w http.ResponseWriter, r *http.Request) {
+			state.encryption = `,"usesNonExemptEncryption":false`
+			fmt.Fprint(w, `{"data":{"id":"b1","type":"builds"}}`)
+		},
+		"GET /builds/b1/app": respond(`{"data":{"id":"a1","type":"apps"}}`),
+		"GET /builds/b1/betaBuildLocalizations": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data":[{"id":"l1","type":"betaBuildLocalizations","attributes":{"locale":"en-US","whatsNew":%q}}]}`, state.whatsNew)
+		},
+		"PATCH /betaBuildLocalizations/l1": func(w http.ResponseWriter, r *http.Request) {
+			state.whatsNew = "Try the new login"
+			fmt.Fprint(w, `{"data":{"id":"l1","type":"betaBuildLocalizations"}}`)
+		},
+		"POST /betaBuildLocalizations": respond(`{"data":{"id":"l2","type":"betaBuildLocalizations"}}`),
+		"GET /apps/a1/betaGroups": respond(`{"data":[
+			{"id":"g1","type":"betaGroups","attributes":{"name":"Internal","isInternalGroup":true}},
+			{"id":"g2","type":"betaGroups","attributes":{"name":"External","isInternalGroup":false}}
+		]}`),
+		"GET /betaGroups/g1/relationships/builds": respond(`{"data":[{"id":"b0","type":"builds"},{"id":"b1","type":"builds"}]}`),
+		"GET /betaGroups/g2/relationships/builds": func(w http.ResponseWriter, r *http.Request) {
+			if state.external {
+				fmt.Fprint(w, `{"data":[{"id":"b1","type":"builds"}]}`)
+			} else {
+				fmt.Fprint(w, `{"data":[]}`)
+			}
+		},
+		"POST /builds/b1/relationships/betaGroups": func(w http.ResponseWriter, r *http.Request) {
+			state.external = true
+			w.WriteHeader(http.StatusNoContent)
+		},
+		"GET /builds/b1/buildBetaDetail": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data":{"id":"d1","type":"buildBetaDetails","attributes":{"autoNotifyEnabled":false,"externalBuildState":%q}}}`, state.externalBuildState)
+		},
+		"POST /betaAppReviewSubmissions": func(w http.ResponseWriter, r *http.Request) {
+			state.externalBuildState = ExternalBetaStateWaitingForBetaReview
+			fmt.Fprint(w, `{"data":{"id":"s1","type":"betaAppReviewSubmissions"}}`)
+		},
+		"POST /buildBetaNotifications": func(w http.ResponseWriter, r *http.Request) {
+			if state.notified {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"errors":[{"status":"409","code":"STATE_ERROR"}]}`)
+
+				return
+			}
+
+			state.notified = true
+			fmt.Fprint(w, `{"data":{"id":"n1","type":"buildBetaNotifications"}}`)
+		},
+	})
+}
+
+func TestDistributeBuild(t *testing.T) {
+	t.Parallel()
+
+	state := &mockDistribution{whatsNew: "Old notes", externalBuildState: ExternalBetaStateReadyForBetaSubmission}
+	client, server := newMockDistributionServer(t, state)
+	spec := DistributionSpec{
+		BuildID:                 "b1",
+		BetaGroups:              []string{"Internal", "External"},
+		WhatToTest:              map[string]string{"en-US": "Try the new login", "fr-FR": "Essayez la connexion"},
+		UsesNonExemptEncryption: Bool(false),
+		Notify:                  true,
+	}
+
+	report, err := client.TestFlight.DistributeBuild(context.Background(), spec)
+	assert.NoError(t, err)
+	assert.Equal(t, ExternalBetaStateWaitingForBetaReview, report.ExternalBetaState)
+	assert.Equal(t, "encryption: done\n"+
+		"whatToTest: done (en-US, fr-FR)\n"+
+		"betaGroups: done (External)\n"+
+		"review: done\n"+
+		"notification: skipped (build is WAITING_FOR_BETA_REVIEW)\n", report.String())
+	assert.Equal(t, []string{
+		"PATCH /builds/b1",
+		"PATCH /betaBuildLocalizations/l1",
+		"POST /betaBuildLocalizations",
+		"POST /builds/b1/relationships/betaGroups",
+		"POST /betaAppReviewSubmissions",
+	}, server.changes())
+
+	// Only fr-FR is created again, since the mock does not remember it.
+	server.reset()
+	state.externalBuildState = ExternalBetaStateReadyForBetaTesting
+
+	report, err = client.TestFlight.DistributeBuild(context.Background(), spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "encryption: skipped\n"+
+		"whatToTest: done (fr-FR)\n"+
+		"betaGroups: skipped\n"+
+		"review: skipped (READY_FOR_BETA_TESTING)\n"+
+		"notification: done\n", report.String())
+	assert.Equal(t, []string{"POST /betaBuildLocalizations", "POST /buildBetaNotifications"}, server.changes())
+
+	spec.WhatToTest = nil
+	report, err = client.TestFlight.DistributeBuild(context.Background(), spec)
+	assert.NoError(t, err)
+	assert.Equal(t, DistributionStepResult{Step: DistributionStepNotification, Skipped: true, Detail: "testers were already notified"}, report.Steps[4])
+}
+
+func TestDistributeBuildPreconditions(t *testing.T) {
+	t.Parallel()
+
+	state := &mockDistribution{externalBuildState: ExternalBetaStateRejected, noAttributes: true}
+	client, _ := newMockDistributionServer(t, state)
+
+	report, err := client.TestFlight.DistributeBuild(context.Background(), DistributionSpec{BuildID: "b1"})
+	assert.Equal(t, ErrDistributionPrecondition{
+		Step:   DistributionStepBuild,
+		Reason: "build b1 has not finished processing",
+	}, err)
+	assert.Empty(t, report.Steps)
+
+	state.noAttributes = false
+
+	report, err = client.TestFlight.DistributeBuild(context.Background(), DistributionSpec{BuildID: "b1"})
+	assert.Equal(t, ErrDistributionPrecondition{
+		Step:   DistributionStepEncryption,
+		Reason: "build b1 is missing export compliance information",
+	}, err)
+	assert.Empty(t, report.Steps)
+
+	state.encryption = `,"usesNonExemptEncryption":true`
+
+	_, err = client.TestFlight.DistributeBuild(context.Background(), DistributionSpec{BuildID: "b1", BetaGroups: []string{"Missing"}})
+	assert.Equal(t, ErrUnknownBetaGroup{Name: "Missing"}, err)
+
+	report, err = client.TestFlight.DistributeBuild(context.Background(), DistributionSpec{BuildID: "b1", BetaGroups: []string{"External"}})
+	assert.EqualError(t, err, "distribution step review cannot run: build b1 is in external beta state BETA_REJECTED")
+	assert.Len(t, report.Steps, 3)
+
+	report, err = client.TestFlight.DistributeBuild(context.Background(), DistributionSpec{BuildID: "b1", BetaGroups: []string{"Internal"}, Notify: true})
+	assert.NoError(t, err)
+	assert.Equal(t, DistributionStepResult{Step: DistributionStepReview, Skipped: true, Detail: "no external beta groups"}, report.Steps[3])
+	assert.Equal(t, DistributionStepResult{Step: DistributionStepNotification}, report.Steps[4])
+}
@@ -0,0 +1,358 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxPublicLinkLimit is the largest number of testers App Store Connect lets join a beta group
+// through its public link.
+const maxPublicLinkLimit = 10000
+
+// ErrInvalidPublicLinkLimit happens when a public link limit is outside of the range App Store
+// Connect accepts.
+type ErrInvalidPublicLinkLimit struct {
+	Limit int
+}
+
+func (e ErrInvalidPublicLinkLimit) Error() string {
+	return fmt.Sprintf("public link limit %d is not between 1 and %d", e.Limit, maxPublicLinkLimit)
+}
+
+// PublicLinkUsage describes the public link of a beta group, and how many testers joined
+// through it.
+type PublicLinkUsage struct {
+	BetaGroupID string
+	Name        string
+	Link        string
+	Enabled     bool
+	// Limit is the maximum number of testers that can join through the link. It only applies
+	// when LimitEnabled is set.
+	Limit        int
+	LimitEnabled bool
+	// Testers is the number of testers of the group that joined through the public link.
+	Testers int
+	// TotalTesters is the number of testers of the group, however they joined.
+	TotalTesters int
+}
+
+// Utilization returns the share of the limit used by testers who joined through the link, from
+// 0 to 1. It returns 0 when the link has no limit.
+func (u PublicLinkUsage) Utilization() float64 {
+	if !u.LimitEnabled || u.Limit <= 0 {
+		return 0
+	}
+
+	return float64(u.Testers) / float64(u.Limit)
+}
+
+// Remaining returns how many more testers can join through the link, or -1 when the link has
+// no limit.
+func (u PublicLinkUsage) Remaining() int {
+	if !u.LimitEnabled {
+		return -1
+	}
+
+	if remaining := u.Limit - u.Testers; remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
+
+// PublicLinkReport is the public link usage of every external beta group of an app.
+type PublicLinkReport struct {
+	Groups []PublicLinkUsage
+}
+
+// String formats the report with one line per beta group.
+func (r *PublicLinkReport) String() string {
+	var b strings.Builder
+
+	for _, usage := range r.Groups {
+		status := "disabled"
+		if usage.Enabled {
+			status = "enabled"
+		}
+
+		fmt.Fprintf(&b, "%s: %s, %d of %d testers joined through the link", usage.Name, status, usage.Testers, usage.TotalTesters)
+
+		if usage.LimitEnabled {
+			fmt.Fprintf(&b, ", limit %d (%.0f%%)", usage.Limit, usage.Utilization()*100)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// PublicLinkUsage returns the public link usage of a beta group, counting its testers whose
+// invite type is BetaInviteTypePublicLink.
+func (s *TestflightService) PublicLinkUsage(ctx context.Context, betaGroupID string) (*PublicLinkUsage, error) {
+	res, _, err := s.GetBetaGroup(ctx, betaGroupID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publicLinkUsage(ctx, res.Data)
+}
+
+// PublicLinkReport returns the public link usage of every external beta group of an app.
+// Internal groups are left out, since they cannot have a public link.
+func (s *TestflightService) PublicLinkReport(ctx context.Context, appID string) (*PublicLinkReport, error) {
+	res, _, err := NewPager[BetaGroup](s.client, func(ctx context.Context) (*BetaGroupsResponse, *Response, error) {
+		return s.ListBetaGroupsForApp(ctx, appID, &ListBetaGroupsForAppQuery{Limit: 200})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := new(PublicLinkReport)
+
+	for _, group := range res.Data {
+		if group.Attributes != nil && group.Attributes.IsInternalGroup != nil && *group.Attributes.IsInternalGroup {
+			continue
+		}
+
+		usage, err := s.publicLinkUsage(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Groups = append(report.Groups, *usage)
+	}
+
+	return report, nil
+}
+
+func (s *TestflightService) publicLinkUsage(ctx context.Context, group BetaGroup) (*PublicLinkUsage, error) {
+	usage := &PublicLinkUsage{BetaGroupID: group.ID}
+
+	if attributes := group.Attributes; attributes != nil {
+		usage.Name = stringValue(attributes.Name)
+		usage.Link = stringValue(attributes.PublicLink)
+		usage.Enabled = attributes.PublicLinkEnabled != nil && *attributes.PublicLinkEnabled
+		usage.LimitEnabled = attributes.PublicLinkLimitEnabled != nil && *attributes.PublicLinkLimitEnabled
+
+		if attributes.PublicLinkLimit != nil {
+			usage.Limit = *attributes.PublicLinkLimit
+		}
+	}
+
+	testers, _, err := NewPager[BetaTester](s.client, func(ctx context.Context) (*BetaTestersResponse, *Response, error) {
+		return s.ListBetaTestersForBetaGroup(ctx, group.ID, &ListBetaTestersForBetaGroupQuery{
+			FieldsBetaTesters: []string{"inviteType"},
+			Limit:             200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.TotalTesters = len(testers.Data)
+
+	for _, tester := range testers.Data {
+		if tester.Attributes != nil && tester.Attributes.InviteType != nil && *tester.Attributes.InviteType == BetaInviteTypePublicLink {
+			usage.Testers++
+		}
+	}
+
+	return usage, nil
+}
+
+// EnablePublicLink enables the public link of a beta group, and returns the updated group.
+func (s *TestflightService) EnablePublicLink(ctx context.Context, betaGroupID string) (*BetaGroup, error) {
+	return s.updatePublicLink(ctx, betaGroupID, &BetaGroupUpdateRequestAttributes{PublicLinkEnabled: Bool(true)})
+}
+
+// DisablePublicLink disables the public link of a beta group, so that no more testers can join
+// through it, and returns the updated group. Testers who already joined keep their access.
+func (s *TestflightService) DisablePublicLink(ctx context.Context, betaGroupID string) (*BetaGroup, error) {
+	return s.updatePublicLink(ctx, betaGroupID, &BetaGroupUpdateRequestAttributes{PublicLinkEnabled: Bool(false)})
+}
+
+// RotatePublicLink disables the public link of a beta group and enables it again, such as after
+// the link leaked, and returns the updated group. Compare its PublicLink with the previous link
+// to check that App Store Connect issued a new one.
+func (s *TestflightService) RotatePublicLink(ctx context.Context, betaGroupID string) (*BetaGroup, error) {
+	if _, err := s.DisablePublicLink(ctx, betaGroupID); err != nil {
+		return nil, err
+	}
+
+	return s.EnablePublicLink(ctx, betaGroupID)
+}
+
+// SetPublicLinkLimit limits the number of testers that can join a beta group through its public
+// link, and returns the updated group. The limit must be between 1 and 10,000.
+func (s *TestflightService) SetPublicLinkLimit(ctx context.Context, betaGroupID string, limit int) (*BetaGroup, error) {
+	if limit < 1 || limit > maxPublicLinkLimit {
+		return nil, ErrInvalidPublicLinkLimit{Limit: limit}
+	}
+
+	return s.updatePublicLink(ctx, betaGroupID, &BetaGroupUpdateRequestAttributes{
+		PublicLinkLimit:        Int(limit),
+		PublicLinkLimitEnabled: Bool(true),
+	})
+}
+
+func (s *TestflightService) updatePublicLink(ctx context.Context, betaGroupID string, attributes *BetaGroupUpdateRequestAttributes) (*BetaGroup, error) {
+	res, _, err := s.UpdateBetaGroup(ctx, betaGroupID, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res.Data, nil
+}
+
+// PublicLinkActionKind is the kind of change a PublicLinkAction makes.
+type PublicLinkActionKind string
+
+const (
+	// PublicLinkActionEnforce sets a limit on a public link that had none.
+	PublicLinkActionEnforce PublicLinkActionKind = "enforce"
+	// PublicLinkActionRaise raises the limit of a public link that is nearly used up.
+	PublicLinkActionRaise PublicLinkActionKind = "raise"
+	// PublicLinkActionDisable disables a public link whose limit is used up and cannot be raised.
+	PublicLinkActionDisable PublicLinkActionKind = "disable"
+)
+
+// PublicLinkAction is a change that a PublicLinkPolicy makes to the public link of a beta group.
+type PublicLinkAction struct {
+	Kind     PublicLinkActionKind
+	Usage    PublicLinkUsage
+	OldLimit int
+	NewLimit int
+	// Applied reports whether the change was made. It is false in dry-run mode.
+	Applied bool
+}
+
+// PublicLinkPolicy keeps the limits of the enabled public links of an app in check. Settings that
+// are zero are not applied.
+type PublicLinkPolicy struct {
+	// EnforceLimit is the limit set on public links that have none. It is raised to the number of
+	// testers who already joined through the link, if that is higher.
+	EnforceLimit int
+	// RaiseAt is the utilization, from 0 to 1, at or above which a limit is raised by RaiseBy.
+	RaiseAt float64
+	RaiseBy int
+	// MaxLimit caps the limits set by the policy. Defaults to 10,000, the largest limit App Store
+	// Connect accepts.
+	MaxLimit int
+	// DisableWhenFull disables public links whose limit is used up and cannot be raised further.
+	DisableWhenFull bool
+	// DryRun decides on the actions without applying them.
+	DryRun bool
+}
+
+// ApplyPublicLinkPolicy applies a PublicLinkPolicy to every enabled public link of an app, and
+// returns the actions it decided on along with the usage report they were based on. The first
+// error stops it.
+func (s *TestflightService) ApplyPublicLinkPolicy(ctx context.Context, appID string, policy PublicLinkPolicy) ([]PublicLinkAction, *PublicLinkReport, error) {
+	report, err := s.PublicLinkReport(ctx, appID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var actions []PublicLinkAction
+
+	for _, usage := range report.Groups {
+		action, ok := policy.decide(usage)
+		if !ok {
+			continue
+		}
+
+		if !policy.DryRun {
+			if action.Kind == PublicLinkActionDisable {
+				_, err = s.DisablePublicLink(ctx, usage.BetaGroupID)
+			} else {
+				_, err = s.SetPublicLinkLimit(ctx, usage.BetaGroupID, action.NewLimit)
+			}
+
+			if err != nil {
+				return actions, report, fmt.Errorf("%s public link of %s: %w", action.Kind, usage.Name, err)
+			}
+
+			action.Applied = true
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, report, nil
+}
+
+// decide returns the action the policy takes on a public link, if any.
+func (p PublicLinkPolicy) decide(usage PublicLinkUsage) (PublicLinkAction, bool) {
+	action := PublicLinkAction{Usage: usage, OldLimit: usage.Limit}
+
+	if !usage.Enabled {
+		return action, false
+	}
+
+	maxLimit := p.MaxLimit
+	if maxLimit <= 0 || maxLimit > maxPublicLinkLimit {
+		maxLimit = maxPublicLinkLimit
+	}
+
+	if !usage.LimitEnabled {
+		if p.EnforceLimit <= 0 {
+			return action, false
+		}
+
+		action.Kind = PublicLinkActionEnforce
+		action.OldLimit = 0
+		action.NewLimit = p.EnforceLimit
+
+		if usage.Testers > action.NewLimit {
+			action.NewLimit = usage.Testers
+		}
+
+		if action.NewLimit > maxLimit {
+			action.NewLimit = maxLimit
+		}
+
+		return action, true
+	}
+
+	if p.RaiseAt > 0 && p.RaiseBy > 0 && usage.Utilization() >= p.RaiseAt && usage.Limit < maxLimit {
+		action.Kind = PublicLinkActionRaise
+		action.NewLimit = usage.Limit + p.RaiseBy
+
+		if action.NewLimit > maxLimit {
+			action.NewLimit = maxLimit
+		}
+
+		return action, true
+	}
+
+	if p.DisableWhenFull && usage.Remaining() == 0 {
+		action.Kind = PublicLinkActionDisable
+		action.NewLimit = usage.Limit
+
+		return action, true
+	}
+
+	return action, false
+}
@@ -0,0 +1,189 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockPublicLinksServer serves app "a1", which has the internal beta group "Internal" (g1),
+// the external beta group "Friends" (g2) with a public link limited to 10 testers, 9 of whom
+// joined through it, and the external beta group "Public" (g3) with an unlimited public link. It
+// records every update made to a beta group, along with its body.
+func newMockPublicLinksServer(t *testing.T) (*Client, *[]string) {
+	t.Helper()
+
+	var requests []string
+
+	update := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+		fmt.Fprintf(w, `{"data":{"id":%q,"type":"betaGroups"}}`, strings.TrimPrefix(r.URL.Path, "/betaGroups/"))
+	}
+
+	client, _ := newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /apps/a1/betaGroups": respond(`{"data":[
+			{"id":"g1","type":"betaGroups","attributes":{"name":"Internal","isInternalGroup":true}},
+			{"id":"g2","type":"betaGroups","attributes":{"name":"Friends","isInternalGroup":false,"publicLink":"https://testflight.apple.com/join/abc","publicLinkEnabled":true,"publicLinkLimit":10,"publicLinkLimitEnabled":true}},
+			{"id":"g3","type":"betaGroups","attributes":{"name":"Public","isInternalGroup":false,"publicLink":"https://testflight.apple.com/join/def","publicLinkEnabled":true}}
+		]}`),
+		"GET /betaGroups/g2": respond(`{"data":{"id":"g2","type":"betaGroups","attributes":{"name":"Friends","publicLinkEnabled":true,"publicLinkLimit":10,"publicLinkLimitEnabled":true}}}`),
+		"GET /betaGroups/g2/betaTesters": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "inviteType", r.URL.Query().Get("fields[betaTesters]"))
+			fmt.Fprint(w, mockBetaTesters(9, 1))
+		},
+		"GET /betaGroups/g3/betaTesters": respond(mockBetaTesters(3, 0)),
+		"PATCH /betaGroups/g2":           update,
+		"PATCH /betaGroups/g3":           update,
+	})
+
+	return client, &requests
+}
+
+func mockBetaTesters(publicLink, email int) string {
+	testers := make([]string, 0, publicLink+email)
+
+	for i := 0; i < publicLink+email; i++ {
+		inviteType := BetaInviteTypePublicLink
+		if i >= publicLink {
+			inviteType = BetaInviteTypeEmail
+		}
+
+		testers = append(testers, fmt.Sprintf(`{"id":"t%d","type":"betaTesters","attributes":{"inviteType":%q}}`, i, inviteType))
+	}
+
+	return `{"data":[` + strings.Join(testers, ",") + `]}`
+}
+
+func TestPublicLinkReport(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newMockPublicLinksServer(t)
+
+	report, err := client.TestFlight.PublicLinkReport(context.Background(), "a1")
+	assert.NoError(t, err)
+	assert.Equal(t, []PublicLinkUsage{
+		{
+			BetaGroupID:  "g2",
+			Name:         "Friends",
+			Link:         "https://testflight.apple.com/join/abc",
+			Enabled:      true,
+			Limit:        10,
+			LimitEnabled: true,
+			Testers:      9,
+			TotalTesters: 10,
+		},
+		{
+			BetaGroupID:  "g3",
+			Name:         "Public",
+			Link:         "https://testflight.apple.com/join/def",
+			Enabled:      true,
+			Testers:      3,
+			TotalTesters: 3,
+		},
+	}, report.Groups)
+	assert.InDelta(t, 0.9, report.Groups[0].Utilization(), 0.0001)
+	assert.Equal(t, 1, report.Groups[0].Remaining())
+	assert.Equal(t, -1, report.Groups[1].Remaining())
+	assert.Equal(t, "Friends: enabled, 9 of 10 testers joined through the link, limit 10 (90%)\nPublic: enabled, 3 of 3 testers joined through the link\n", report.String())
+
+	usage, err := client.TestFlight.PublicLinkUsage(context.Background(), "g2")
+	assert.NoError(t, err)
+	assert.Equal(t, 9, usage.Testers)
+}
+
+func TestApplyPublicLinkPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := PublicLinkPolicy{EnforceLimit: 100, RaiseAt: 0.8, RaiseBy: 25}
+
+	client, requests := newMockPublicLinksServer(t)
+
+	policy.DryRun = true
+	actions, _, err := client.TestFlight.ApplyPublicLinkPolicy(context.Background(), "a1", policy)
+	assert.NoError(t, err)
+	assert.Len(t, actions, 2)
+	assert.Empty(t, *requests)
+
+	policy.DryRun = false
+	actions, _, err = client.TestFlight.ApplyPublicLinkPolicy(context.Background(), "a1", policy)
+	assert.NoError(t, err)
+	assert.Len(t, actions, 2)
+	assert.Equal(t, PublicLinkActionRaise, actions[0].Kind)
+	assert.Equal(t, 10, actions[0].OldLimit)
+	assert.Equal(t, 35, actions[0].NewLimit)
+	assert.True(t, actions[0].Applied)
+	assert.Equal(t, PublicLinkActionEnforce, actions[1].Kind)
+	assert.Equal(t, 100, actions[1].NewLimit)
+	assert.Equal(t, []string{
+		`PATCH /betaGroups/g2 {"data":{"attributes":{"publicLinkLimit":35,"publicLinkLimitEnabled":true},"id":"g2","type":"betaGroups"}}` + "\n",
+		`PATCH /betaGroups/g3 {"data":{"attributes":{"publicLinkLimit":100,"publicLinkLimitEnabled":true},"id":"g3","type":"betaGroups"}}` + "\n",
+	}, *requests)
+}
+
+func TestPublicLinkPolicyDecide(t *testing.T) {
+	t.Parallel()
+
+	full := PublicLinkUsage{Enabled: true, Limit: 10000, LimitEnabled: true, Testers: 10000}
+
+	action, ok := PublicLinkPolicy{RaiseAt: 0.9, RaiseBy: 100}.decide(full)
+	assert.False(t, ok, action)
+
+	action, ok = PublicLinkPolicy{RaiseAt: 0.9, RaiseBy: 100, DisableWhenFull: true}.decide(full)
+	assert.True(t, ok)
+	assert.Equal(t, PublicLinkActionDisable, action.Kind)
+
+	action, ok = PublicLinkPolicy{RaiseAt: 0.5, RaiseBy: 100, MaxLimit: 50}.decide(PublicLinkUsage{Enabled: true, Limit: 40, LimitEnabled: true, Testers: 30})
+	assert.True(t, ok)
+	assert.Equal(t, 50, action.NewLimit)
+
+	action, ok = PublicLinkPolicy{EnforceLimit: 5}.decide(PublicLinkUsage{Enabled: true, Testers: 8})
+	assert.True(t, ok)
+	assert.Equal(t, 8, action.NewLimit)
+
+	_, ok = PublicLinkPolicy{EnforceLimit: 5}.decide(PublicLinkUsage{Testers: 8})
+	assert.False(t, ok)
+}
+
+func TestPublicLinkManagement(t *testing.T) {
+	t.Parallel()
+
+	client, requests := newMockPublicLinksServer(t)
+
+	_, err := client.TestFlight.RotatePublicLink(context.Background(), "g2")
+	assert.NoError(t, err)
+
+	_, err = client.TestFlight.SetPublicLinkLimit(context.Background(), "g2", 0)
+	assert.Equal(t, ErrInvalidPublicLinkLimit{Limit: 0}, err)
+
+	assert.Equal(t, []string{
+		`PATCH /betaGroups/g2 {"data":{"attributes":{"publicLinkEnabled":false},"id":"g2","type":"betaGroups"}}` + "\n",
+		`PATCH /betaGroups/g2 {"data":{"attributes":{"publicLinkEnabled":true},"id":"g2","type":"betaGroups"}}` + "\n",
+	}, *requests)
+}
@@ -0,0 +1,654 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// rosterBatchSize is the number of testers added to or removed from a beta group per request.
+const rosterBatchSize = 50
+
+// ErrMissingRosterEmail happens when a CSV roster has no email column.
+var ErrMissingRosterEmail = errors.New("roster has no email column")
+
+// ErrUnknownBetaGroup happens when a roster refers to a beta group that the app does not have.
+type ErrUnknownBetaGroup struct {
+	Name string
+}
+
+func (e ErrUnknownBetaGroup) Error() string {
+	return fmt.Sprintf("app has no beta group named %s", e.Name)
+}
+
+// RosterTester is a tester of a Roster, and the names of the beta groups they belong to.
+type RosterTester struct {
+	Email     string   `json:"email"`
+	FirstName string   `json:"firstName,omitempty"`
+	LastName  string   `json:"lastName,omitempty"`
+	Groups    []string `json:"groups,omitempty"`
+}
+
+// Roster is the desired list of TestFlight testers of an app. It can be loaded from CSV or JSON
+// with LoadRoster.
+type Roster struct {
+	Testers []RosterTester `json:"testers"`
+}
+
+// LoadRoster loads a Roster from path. Files ending in ".csv" are read with ReadRosterCSV, and
+// all other files with ReadRosterJSON.
+func LoadRoster(path string) (*Roster, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer closeDesc(file)
+
+	var roster *Roster
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		roster, err = ReadRosterCSV(file)
+	} else {
+		roster, err = ReadRosterJSON(file)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return roster, nil
+}
+
+// ReadRosterJSON reads a Roster from JSON, either as a Roster document or as an array of
+// RosterTester.
+func ReadRosterJSON(r io.Reader) (*Roster, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	roster := new(Roster)
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = json.Unmarshal(data, &roster.Testers)
+	} else {
+		err = json.Unmarshal(data, roster)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return roster, nil
+}
+
+// ReadRosterCSV reads a Roster from CSV. The first row names the columns, which are email,
+// first name, last name and groups, in any order and spelled as in "firstName", "first_name" or
+// "First Name". Only email is required. Groups are separated by semicolons:
+//
+//	email,first_name,last_name,groups
+//	jane@example.com,Jane,Doe,QA;Beta
+func ReadRosterCSV(r io.Reader) (*Roster, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrMissingRosterEmail
+	} else if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[rosterColumn(name)] = i
+	}
+
+	if _, ok := columns["email"]; !ok {
+		return nil, ErrMissingRosterEmail
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+
+		return ""
+	}
+
+	roster := new(Roster)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return roster, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		tester := RosterTester{
+			Email:     field(record, "email"),
+			FirstName: field(record, "firstname"),
+			LastName:  field(record, "lastname"),
+		}
+
+		for _, group := range strings.Split(field(record, "groups"), ";") {
+			if group = strings.TrimSpace(group); group != "" {
+				tester.Groups = append(tester.Groups, group)
+			}
+		}
+
+		roster.Testers = append(roster.Testers, tester)
+	}
+}
+
+// rosterColumn normalizes the name of a CSV column, such that "First Name" and "first_name"
+// become "firstname".
+func rosterColumn(name string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// RosterSyncOptions selects the beta groups a roster sync applies to.
+type RosterSyncOptions struct {
+	// AppID is the app whose beta groups are synced.
+	AppID string
+	// Groups are the names of the beta groups managed by the sync. Membership of other groups is
+	// left alone. If empty, every group named in the roster is managed.
+	Groups []string
+	// Prune removes testers that are not in the roster from the managed groups.
+	Prune bool
+	// DeleteRemoved deletes pruned testers with DeleteBetaTester instead of removing them from
+	// the managed groups. This revokes their access to every app of the team.
+	DeleteRemoved bool
+}
+
+// RosterChangeKind is the kind of change a RosterChange makes.
+type RosterChangeKind string
+
+const (
+	// RosterChangeCreate creates a tester in some beta groups.
+	RosterChangeCreate RosterChangeKind = "create"
+	// RosterChangeAdd adds an existing tester to some beta groups.
+	RosterChangeAdd RosterChangeKind = "add"
+	// RosterChangeRemove removes a tester from some beta groups.
+	RosterChangeRemove RosterChangeKind = "remove"
+	// RosterChangeDelete deletes a tester.
+	RosterChangeDelete RosterChangeKind = "delete"
+)
+
+// RosterChange is a change to the beta groups of a single tester. A tester whose groups are
+// both added and removed has one change of each kind.
+type RosterChange struct {
+	Kind  RosterChangeKind
+	Email string
+	// TesterID is the ID of the existing tester, or empty if it will be created.
+	TesterID string
+	// Groups are the names of the beta groups the tester is added to or removed from.
+	Groups []string
+
+	groupIDs []string
+	tester   RosterTester
+}
+
+// RosterSyncPlan is the minimal set of changes needed to make the beta groups of an app match a
+// Roster. It is returned by TestflightService.PlanRosterSync and applied with
+// TestflightService.ApplyRosterSync.
+type RosterSyncPlan struct {
+	Changes []RosterChange
+}
+
+// String formats the plan for review, with one line per change.
+func (p *RosterSyncPlan) String() string {
+	var b strings.Builder
+
+	for _, change := range p.Changes {
+		b.WriteString(change.String())
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func (c RosterChange) String() string {
+	s := fmt.Sprintf("%s %s", c.Kind, c.Email)
+
+	if c.TesterID != "" {
+		s += fmt.Sprintf(" (%s)", c.TesterID)
+	}
+
+	if len(c.Groups) > 0 {
+		s += fmt.Sprintf(" [%s]", strings.Join(c.Groups, ", "))
+	}
+
+	return s
+}
+
+// RosterSyncResult is the outcome of one change of a roster sync.
+type RosterSyncResult struct {
+	Change RosterChange
+	// Applied reports whether the change was made. It is false in dry-run mode and when Err is set.
+	Applied bool
+	Err     error
+}
+
+// RosterSyncReport holds the result of every change of a roster sync, in the order of the plan.
+type RosterSyncReport struct {
+	Results []RosterSyncResult
+}
+
+// Failed returns the results of the changes that failed.
+func (r *RosterSyncReport) Failed() []RosterSyncResult {
+	var failed []RosterSyncResult
+
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Err returns the error of the first change that failed, or nil if none did.
+func (r *RosterSyncReport) Err() error {
+	for _, result := range r.Results {
+		if result.Err != nil {
+			return fmt.Errorf("%s %s: %w", result.Change.Kind, result.Change.Email, result.Err)
+		}
+	}
+
+	return nil
+}
+
+// String formats the report with one line per change and its outcome.
+func (r *RosterSyncReport) String() string {
+	var b strings.Builder
+
+	for _, result := range r.Results {
+		status := "planned"
+
+		switch {
+		case result.Err != nil:
+			status = result.Err.Error()
+		case result.Applied:
+			status = "ok"
+		}
+
+		fmt.Fprintf(&b, "%s: %s\n", result.Change, status)
+	}
+
+	return b.String()
+}
+
+// SyncRoster makes the beta groups of an app match a Roster, and reports the outcome of every
+// change. If dryRun is true, the changes are reported without being applied. Errors of single
+// changes do not stop the sync, and are reported in the RosterSyncReport.
+func (s *TestflightService) SyncRoster(ctx context.Context, roster *Roster, opts RosterSyncOptions, dryRun bool) (*RosterSyncReport, error) {
+	plan, err := s.PlanRosterSync(ctx, roster, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		report := &RosterSyncReport{Results: make([]RosterSyncResult, len(plan.Changes))}
+		for i, change := range plan.Changes {
+			report.Results[i].Change = change
+		}
+
+		return report, nil
+	}
+
+	return s.ApplyRosterSync(ctx, plan), nil
+}
+
+// rosterLiveTester is a tester of the app, and the managed groups it belongs to.
+type rosterLiveTester struct {
+	id     string
+	email  string
+	groups map[string]bool
+}
+
+// PlanRosterSync compares a Roster with the testers of the managed beta groups of an app, without
+// changing anything. Testers are matched by email address, regardless of case. Testers that do
+// not exist yet are created, and the names of existing testers are left unchanged.
+//
+// The roster is checked with Roster.Validate first, so a *ValidationError is returned before
+// any request is made if it is invalid.
+func (s *TestflightService) PlanRosterSync(ctx context.Context, roster *Roster, opts RosterSyncOptions) (*RosterSyncPlan, error) {
+	if err := roster.Validate(); err != nil {
+		return nil, err
+	}
+
+	groupIDs, managed, err := s.rosterGroups(ctx, roster, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := s.rosterLiveTesters(ctx, roster, groupIDs, managed)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := new(RosterSyncPlan)
+	inRoster := make(map[string]bool, len(roster.Testers))
+
+	for _, tester := range roster.Testers {
+		key := strings.ToLower(tester.Email)
+		inRoster[key] = true
+
+		desired := make(map[string]bool, len(tester.Groups))
+
+		for _, group := range tester.Groups {
+			if contains(managed, group) {
+				desired[group] = true
+			}
+		}
+
+		current, ok := live[key]
+		if !ok {
+			if len(desired) > 0 {
+				plan.add(RosterChangeCreate, tester.Email, "", desired, groupIDs, tester)
+			}
+
+			continue
+		}
+
+		added := make(map[string]bool)
+		removed := make(map[string]bool)
+
+		for group := range desired {
+			if !current.groups[group] {
+				added[group] = true
+			}
+		}
+
+		for group := range current.groups {
+			if !desired[group] {
+				removed[group] = true
+			}
+		}
+
+		plan.add(RosterChangeAdd, tester.Email, current.id, added, groupIDs, tester)
+		plan.add(RosterChangeRemove, tester.Email, current.id, removed, groupIDs, tester)
+	}
+
+	if !opts.Prune {
+		return plan, nil
+	}
+
+	pruned := make([]*rosterLiveTester, 0, len(live))
+
+	for key, tester := range live {
+		if !inRoster[key] && len(tester.groups) > 0 {
+			pruned = append(pruned, tester)
+		}
+	}
+
+	sort.Slice(pruned, func(i, j int) bool { return pruned[i].email < pruned[j].email })
+
+	for _, tester := range pruned {
+		if opts.DeleteRemoved {
+			plan.Changes = append(plan.Changes, RosterChange{Kind: RosterChangeDelete, Email: tester.email, TesterID: tester.id})
+		} else {
+			plan.add(RosterChangeRemove, tester.email, tester.id, tester.groups, groupIDs, RosterTester{Email: tester.email})
+		}
+	}
+
+	return plan, nil
+}
+
+// add appends a change for a set of groups, ordered by name, unless the set is empty.
+func (p *RosterSyncPlan) add(kind RosterChangeKind, email string, testerID string, groups map[string]bool, groupIDs map[string]string, tester RosterTester) {
+	if len(groups) == 0 {
+		return
+	}
+
+	change := RosterChange{Kind: kind, Email: email, TesterID: testerID, tester: tester}
+
+	for group := range groups {
+		change.Groups = append(change.Groups, group)
+	}
+
+	sort.Strings(change.Groups)
+
+	for _, group := range change.Groups {
+		change.groupIDs = append(change.groupIDs, groupIDs[group])
+	}
+
+	p.Changes = append(p.Changes, change)
+}
+
+// rosterGroups returns the IDs of the beta groups of the app by name, and the names of the
+// managed groups.
+func (s *TestflightService) rosterGroups(ctx context.Context, roster *Roster, opts RosterSyncOptions) (map[string]string, []string, error) {
+	res, _, err := NewPager[BetaGroup](s.client, func(ctx context.Context) (*BetaGroupsResponse, *Response, error) {
+		return s.ListBetaGroupsForApp(ctx, opts.AppID, &ListBetaGroupsForAppQuery{
+			FieldsBetaGroups: []string{"name"},
+			Limit:            200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groupIDs := make(map[string]string, len(res.Data))
+
+	for _, group := range res.Data {
+		if group.Attributes != nil && group.Attributes.Name != nil {
+			groupIDs[*group.Attributes.Name] = group.ID
+		}
+	}
+
+	managed := opts.Groups
+	if len(managed) == 0 {
+		for _, tester := range roster.Testers {
+			for _, group := range tester.Groups {
+				if !contains(managed, group) {
+					managed = append(managed, group)
+				}
+			}
+		}
+	}
+
+	for _, group := range managed {
+		if _, ok := groupIDs[group]; !ok {
+			return nil, nil, ErrUnknownBetaGroup{Name: group}
+		}
+	}
+
+	return groupIDs, managed, nil
+}
+
+// rosterLiveTesters returns the testers of the managed groups, and the testers of the roster
+// that exist outside of them, keyed by lower case email address.
+func (s *TestflightService) rosterLiveTesters(ctx context.Context, roster *Roster, groupIDs map[string]string, managed []string) (map[string]*rosterLiveTester, error) {
+	live := make(map[string]*rosterLiveTester)
+
+	track := func(tester BetaTester) *rosterLiveTester {
+		if tester.Attributes == nil || tester.Attributes.Email == nil {
+			return nil
+		}
+
+		email := string(*tester.Attributes.Email)
+		key := strings.ToLower(email)
+
+		if _, ok := live[key]; !ok {
+			live[key] = &rosterLiveTester{id: tester.ID, email: email, groups: make(map[string]bool)}
+		}
+
+		return live[key]
+	}
+
+	for _, group := range managed {
+		groupID := groupIDs[group]
+
+		res, _, err := NewPager[BetaTester](s.client, func(ctx context.Context) (*BetaTestersResponse, *Response, error) {
+			return s.ListBetaTestersForBetaGroup(ctx, groupID, &ListBetaTestersForBetaGroupQuery{
+				FieldsBetaTesters: []string{"email"},
+				Limit:             200,
+			})
+		}).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tester := range res.Data {
+			if current := track(tester); current != nil {
+				current.groups[group] = true
+			}
+		}
+	}
+
+	var missing []string
+
+	for _, tester := range roster.Testers {
+		if _, ok := live[strings.ToLower(tester.Email)]; !ok && len(tester.Groups) > 0 {
+			missing = append(missing, tester.Email)
+		}
+	}
+
+	for start := 0; start < len(missing); start += rosterBatchSize {
+		end := start + rosterBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		res, _, err := NewPager[BetaTester](s.client, func(ctx context.Context) (*BetaTestersResponse, *Response, error) {
+			return s.ListBetaTesters(ctx, &ListBetaTestersQuery{
+				FieldsBetaTesters: []string{"email"},
+				FilterEmail:       missing[start:end],
+				Limit:             200,
+			})
+		}).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tester := range res.Data {
+			track(tester)
+		}
+	}
+
+	return live, nil
+}
+
+// ApplyRosterSync applies a plan returned by PlanRosterSync. Testers are created and deleted one
+// by one, while testers added to or removed from a beta group are batched into as few requests
+// as possible. Errors do not stop the sync, and are reported with the change they belong to.
+func (s *TestflightService) ApplyRosterSync(ctx context.Context, plan *RosterSyncPlan) *RosterSyncReport {
+	report := &RosterSyncReport{Results: make([]RosterSyncResult, len(plan.Changes))}
+
+	for i, change := range plan.Changes {
+		report.Results[i].Change = change
+	}
+
+	for i, change := range plan.Changes {
+		var err error
+
+		switch change.Kind {
+		case RosterChangeCreate:
+			attributes := BetaTesterCreateRequestAttributes{Email: Email(change.tester.Email)}
+			if change.tester.FirstName != "" {
+				attributes.FirstName = String(change.tester.FirstName)
+			}
+
+			if change.tester.LastName != "" {
+				attributes.LastName = String(change.tester.LastName)
+			}
+
+			_, _, err = s.CreateBetaTester(ctx, attributes, change.groupIDs, nil)
+		case RosterChangeDelete:
+			_, err = s.DeleteBetaTester(ctx, change.TesterID)
+		default:
+			continue
+		}
+
+		report.Results[i].Err = err
+	}
+
+	s.applyRosterLinkages(ctx, plan, report, RosterChangeAdd, s.AddBetaTestersToBetaGroup)
+	s.applyRosterLinkages(ctx, plan, report, RosterChangeRemove, s.RemoveBetaTestersFromBetaGroup)
+
+	for i := range report.Results {
+		report.Results[i].Applied = report.Results[i].Err == nil
+	}
+
+	return report
+}
+
+// applyRosterLinkages applies the changes of a kind with one request per batch of testers of
+// each beta group. A failed request fails every change in its batch.
+func (s *TestflightService) applyRosterLinkages(ctx context.Context, plan *RosterSyncPlan, report *RosterSyncReport, kind RosterChangeKind, link func(ctx context.Context, id string, betaTesterIDs []string) (*Response, error)) {
+	changesByGroup := make(map[string][]int)
+
+	var groupIDs []string
+
+	for i, change := range plan.Changes {
+		if change.Kind != kind {
+			continue
+		}
+
+		for _, groupID := range change.groupIDs {
+			if _, ok := changesByGroup[groupID]; !ok {
+				groupIDs = append(groupIDs, groupID)
+			}
+
+			changesByGroup[groupID] = append(changesByGroup[groupID], i)
+		}
+	}
+
+	for _, groupID := range groupIDs {
+		changes := changesByGroup[groupID]
+
+		for start := 0; start < len(changes); start += rosterBatchSize {
+			end := start + rosterBatchSize
+			if end > len(changes) {
+				end = len(changes)
+			}
+
+			batch := changes[start:end]
+			testerIDs := make([]string, len(batch))
+
+			for j, i := range batch {
+				testerIDs[j] = plan.Changes[i].TesterID
+			}
+
+			if _, err := link(ctx, groupID, testerIDs); err != nil {
+				for _, i := range batch {
+					if report.Results[i].Err == nil {
+						report.Results[i].Err = err
+					}
+				}
+			}
+		}
+	}
+}
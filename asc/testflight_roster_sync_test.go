@@ -0,0 +1,203 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockRosterServer serves an app with the beta groups QA (g1), Beta (g2) and Other (g3). QA
+// has alice (t1) and bob (t2), Beta has alice and carol (t3), and dave (t4) is a tester of the
+// team outside of any group. It records every request that changes a tester, along with its data.
+func newMockRosterServer(t *testing.T) (*Client, *[]string) {
+	t.Helper()
+
+	var requests []string
+
+	tester := func(id, email string) string {
+		return fmt.Sprintf(`{"id":%q,"type":"betaTesters","attributes":{"email":%q}}`, id, email)
+	}
+
+	change := func(status int, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			raw, _ := io.ReadAll(r.Body)
+
+			var payload struct {
+				Data json.RawMessage `json:"data"`
+			}
+
+			_ = json.Unmarshal(raw, &payload)
+			requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, payload.Data))
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+		}
+	}
+
+	conflict := change(http.StatusConflict, `{"errors":[{"status":"409","code":"ENTITY_ERROR"}]}`)
+
+	client, _ := newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /apps/app1/betaGroups": respond(`{"data":[
+			{"id":"g1","type":"betaGroups","attributes":{"name":"QA"}},
+			{"id":"g2","type":"betaGroups","attributes":{"name":"Beta"}},
+			{"id":"g3","type":"betaGroups","attributes":{"name":"Other"}}
+		]}`),
+		"GET /betaGroups/g1/betaTesters": respond(fmt.Sprintf(`{"data":[%s,%s]}`, tester("t1", "alice@example.com"), tester("t2", "bob@example.com"))),
+		"GET /betaGroups/g2/betaTesters": respond(fmt.Sprintf(`{"data":[%s,%s]}`, tester("t1", "Alice@example.com"), tester("t3", "carol@example.com"))),
+		"GET /betaTesters": func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.URL.Query().Get("filter[email]"), "dave@example.com") {
+				fmt.Fprintf(w, `{"data":[%s]}`, tester("t4", "dave@example.com"))
+			} else {
+				fmt.Fprint(w, `{"data":[]}`)
+			}
+		},
+		"POST /betaTesters":                               change(http.StatusCreated, `{"data":{"id":"t5","type":"betaTesters"}}`),
+		"POST /betaGroups/g1/relationships/betaTesters":   change(http.StatusNoContent, ""),
+		"DELETE /betaGroups/g1/relationships/betaTesters": change(http.StatusNoContent, ""),
+		"POST /betaGroups/g2/relationships/betaTesters":   conflict,
+		"DELETE /betaGroups/g2/relationships/betaTesters": conflict,
+	})
+
+	return client, &requests
+}
+
+func testRoster() *Roster {
+	return &Roster{Testers: []RosterTester{
+		{Email: "ALICE@example.com", Groups: []string{"QA"}},
+		{Email: "dave@example.com", Groups: []string{"QA", "Beta"}},
+		{Email: "erin@example.com", FirstName: "Erin", Groups: []string{"Beta"}},
+	}}
+}
+
+func TestPlanRosterSync(t *testing.T) {
+	t.Parallel()
+
+	client, requests := newMockRosterServer(t)
+
+	plan, err := client.TestFlight.PlanRosterSync(context.Background(), testRoster(), RosterSyncOptions{AppID: "app1", Prune: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "remove ALICE@example.com (t1) [Beta]\n"+
+		"add dave@example.com (t4) [Beta, QA]\n"+
+		"create erin@example.com [Beta]\n"+
+		"remove bob@example.com (t2) [QA]\n"+
+		"remove carol@example.com (t3) [Beta]\n", plan.String())
+	assert.Empty(t, *requests)
+
+	plan, err = client.TestFlight.PlanRosterSync(context.Background(), testRoster(), RosterSyncOptions{AppID: "app1", Groups: []string{"QA"}, Prune: true, DeleteRemoved: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "add dave@example.com (t4) [QA]\ndelete bob@example.com (t2)\n", plan.String())
+
+	_, err = client.TestFlight.PlanRosterSync(context.Background(), testRoster(), RosterSyncOptions{AppID: "app1", Groups: []string{"Missing"}})
+	assert.Equal(t, ErrUnknownBetaGroup{Name: "Missing"}, err)
+
+	_, err = client.TestFlight.PlanRosterSync(context.Background(), &Roster{Testers: []RosterTester{
+		{Email: "frank@example.com"},
+		{Email: "FRANK@example.com", Groups: []string{" "}},
+		{Email: "not an email"},
+	}}, RosterSyncOptions{AppID: "app1"})
+	assert.Equal(t, []Violation{
+		{Pointer: "/testers/1/email", Code: ViolationDuplicate, Detail: "email FRANK@example.com is already used by tester 0"},
+		{Pointer: "/testers/1/groups/0", Code: ViolationRequired, Detail: "group name is required"},
+		{Pointer: "/testers/2/email", Code: ViolationInvalidEmail, Detail: "email is not a valid email address"},
+	}, violationsOf(t, err))
+}
+
+func TestSyncRoster(t *testing.T) {
+	t.Parallel()
+
+	client, requests := newMockRosterServer(t)
+
+	report, err := client.TestFlight.SyncRoster(context.Background(), testRoster(), RosterSyncOptions{AppID: "app1", Prune: true}, true)
+	assert.NoError(t, err)
+	assert.NoError(t, report.Err())
+	assert.Contains(t, report.String(), "create erin@example.com [Beta]: planned\n")
+	assert.Empty(t, *requests)
+
+	report, err = client.TestFlight.SyncRoster(context.Background(), testRoster(), RosterSyncOptions{AppID: "app1", Prune: true}, false)
+	assert.NoError(t, err)
+
+	sort.Strings(*requests)
+	assert.Equal(t, []string{
+		`DELETE /betaGroups/g1/relationships/betaTesters [{"id":"t2","type":"betaTesters"}]`,
+		`DELETE /betaGroups/g2/relationships/betaTesters [{"id":"t1","type":"betaTesters"},{"id":"t3","type":"betaTesters"}]`,
+		`POST /betaGroups/g1/relationships/betaTesters [{"id":"t4","type":"betaTesters"}]`,
+		`POST /betaGroups/g2/relationships/betaTesters [{"id":"t4","type":"betaTesters"}]`,
+		`POST /betaTesters {"attributes":{"email":"erin@example.com","firstName":"Erin"},"relationships":{"betaGroups":{"data":[{"id":"g2","type":"betaGroups"}]}},"type":"betaTesters"}`,
+	}, *requests)
+
+	failed := report.Failed()
+	assert.Len(t, failed, 3)
+
+	for _, result := range failed {
+		assert.False(t, result.Applied)
+		assert.ErrorIs(t, result.Err, ErrConflict)
+		assert.Contains(t, result.Change.Groups, "Beta")
+	}
+
+	assert.True(t, report.Results[2].Applied)
+	assert.True(t, report.Results[3].Applied)
+	assert.ErrorIs(t, report.Err(), ErrConflict)
+	assert.True(t, strings.HasPrefix(report.Err().Error(), "remove ALICE@example.com: "))
+}
+
+func TestLoadRoster(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "roster.csv")
+	jsonPath := filepath.Join(dir, "roster.json")
+	want := &Roster{Testers: []RosterTester{
+		{Email: "jane@example.com", FirstName: "Jane", LastName: "Doe", Groups: []string{"QA", "Beta"}},
+		{Email: "john@example.com"},
+	}}
+
+	assert.NoError(t, os.WriteFile(csvPath, []byte("Email, First Name,last_name,groups\njane@example.com,Jane,Doe,QA; Beta\njohn@example.com,,,\n"), 0o600))
+	assert.NoError(t, os.WriteFile(jsonPath, []byte(`[
+		{"email":"jane@example.com","firstName":"Jane","lastName":"Doe","groups":["QA","Beta"]},
+		{"email":"john@example.com"}
+	]`), 0o600))
+
+	roster, err := LoadRoster(csvPath)
+	assert.NoError(t, err)
+	assert.Equal(t, want, roster)
+
+	roster, err = LoadRoster(jsonPath)
+	assert.NoError(t, err)
+	assert.Equal(t, want, roster)
+
+	roster, err = ReadRosterJSON(strings.NewReader(`{"testers":[{"email":"john@example.com"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, want.Testers[1:], roster.Testers)
+
+	_, err = ReadRosterCSV(strings.NewReader("name,groups\nJane,QA\n"))
+	assert.Equal(t, ErrMissingRosterEmail, err)
+}
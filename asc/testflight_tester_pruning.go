@@ -0,0 +1,562 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TesterPruneRules decide which testers of an app are stale. Testers matching any enabled rule
+// are pruned. Members of internal beta groups are never pruned, since they are users of the team
+// and do not count against the limit of external testers.
+//
+// App Store Connect does not report whether a tester installed a build, so staleness is judged
+// from the builds a tester can still reach, through their beta groups or builds assigned to them
+// individually.
+type TesterPruneRules struct {
+	// AppID is the app whose testers are pruned.
+	AppID string
+	// NoAccess prunes testers who cannot reach any build, either because they belong to no beta
+	// group of the app or because their groups have no builds.
+	NoAccess bool
+	// ExpiredBuilds prunes testers whose builds have all expired.
+	ExpiredBuilds bool
+	// ExpiredFor is how long ago the last build of a tester must have expired for ExpiredBuilds
+	// to prune them.
+	ExpiredFor time.Duration
+	// InviteTypes limits pruning to testers invited in one of these ways. If empty, testers are
+	// pruned however they were invited.
+	InviteTypes []BetaInviteType
+	// KeepGroups are the names of beta groups whose members are never pruned.
+	KeepGroups []string
+	// Delete deletes stale testers with DeleteBetaTester instead of removing their access to the
+	// app. This revokes their access to every app of the team.
+	Delete bool
+}
+
+// TesterPruneReason is the rule that makes a tester stale.
+type TesterPruneReason string
+
+const (
+	// TesterPruneNoAccess is the reason of testers pruned by TesterPruneRules.NoAccess.
+	TesterPruneNoAccess TesterPruneReason = "no access"
+	// TesterPruneExpiredBuilds is the reason of testers pruned by TesterPruneRules.ExpiredBuilds.
+	TesterPruneExpiredBuilds TesterPruneReason = "expired builds"
+)
+
+// StaleTester is a tester a prune removes, and why.
+type StaleTester struct {
+	TesterID   string
+	Email      string
+	InviteType BetaInviteType
+	// Groups are the names of the beta groups of the app the tester belongs to.
+	Groups []string
+	Reason TesterPruneReason
+	// Detail explains the reason, such as when the last build of the tester expired.
+	Detail string
+}
+
+func (t StaleTester) String() string {
+	s := fmt.Sprintf("%s (%s): %s", t.Email, t.TesterID, t.Reason)
+
+	if t.Detail != "" {
+		s += ", " + t.Detail
+	}
+
+	return s
+}
+
+// TesterPrunePlan lists the stale testers of an app. It is returned by
+// TestflightService.PlanTesterPrune and applied with TestflightService.ApplyTesterPrune.
+type TesterPrunePlan struct {
+	AppID  string
+	Delete bool
+	// Scanned is the number of testers of the app that were checked.
+	Scanned int
+	Testers []StaleTester
+}
+
+// String formats the plan for review, with one line per stale tester.
+func (p *TesterPrunePlan) String() string {
+	var b strings.Builder
+
+	for _, tester := range p.Testers {
+		fmt.Fprintf(&b, "%s %s\n", p.action(), tester)
+	}
+
+	return b.String()
+}
+
+func (p *TesterPrunePlan) action() string {
+	if p.Delete {
+		return "delete"
+	}
+
+	return "remove"
+}
+
+// TesterPruneEntry is the outcome of pruning one tester.
+type TesterPruneEntry struct {
+	Tester StaleTester
+	// Action is "delete" when the tester is deleted, or "remove" when their access to the app is
+	// removed.
+	Action string
+	// Applied reports whether the tester was pruned. It is false in dry-run mode and when Err is
+	// set.
+	Applied bool
+	Err     error
+}
+
+// TesterPruneReport is the audit log of a prune, with one entry per stale tester in the order
+// of the plan. It can be exported with WriteCSV or WriteJSON.
+type TesterPruneReport struct {
+	AppID string
+	// Time is when the prune ran.
+	Time    time.Time
+	Entries []TesterPruneEntry
+}
+
+// Failed returns the entries of the testers that could not be pruned.
+func (r *TesterPruneReport) Failed() []TesterPruneEntry {
+	var failed []TesterPruneEntry
+
+	for _, entry := range r.Entries {
+		if entry.Err != nil {
+			failed = append(failed, entry)
+		}
+	}
+
+	return failed
+}
+
+// Err returns the error of the first tester that could not be pruned, or nil if none failed.
+func (r *TesterPruneReport) Err() error {
+	for _, entry := range r.Entries {
+		if entry.Err != nil {
+			return fmt.Errorf("%s %s: %w", entry.Action, entry.Tester.Email, entry.Err)
+		}
+	}
+
+	return nil
+}
+
+// String formats the report with one line per tester and its outcome.
+func (r *TesterPruneReport) String() string {
+	var b strings.Builder
+
+	for _, entry := range r.Entries {
+		fmt.Fprintf(&b, "%s %s: %s\n", entry.Action, entry.Tester, entry.status())
+	}
+
+	return b.String()
+}
+
+func (e TesterPruneEntry) status() string {
+	switch {
+	case e.Err != nil:
+		return e.Err.Error()
+	case e.Applied:
+		return "ok"
+	default:
+		return "planned"
+	}
+}
+
+// testerPruneRecord is the exported form of a TesterPruneEntry.
+type testerPruneRecord struct {
+	Time       string   `json:"time"`
+	AppID      string   `json:"app_id"`
+	TesterID   string   `json:"tester_id"`
+	Email      string   `json:"email"`
+	InviteType string   `json:"invite_type,omitempty"`
+	Groups     []string `json:"groups"`
+	Reason     string   `json:"reason"`
+	Detail     string   `json:"detail,omitempty"`
+	Action     string   `json:"action"`
+	Status     string   `json:"status"`
+}
+
+func (r *TesterPruneReport) records() []testerPruneRecord {
+	records := make([]testerPruneRecord, len(r.Entries))
+
+	for i, entry := range r.Entries {
+		records[i] = testerPruneRecord{
+			Time:       r.Time.UTC().Format(time.RFC3339),
+			AppID:      r.AppID,
+			TesterID:   entry.Tester.TesterID,
+			Email:      entry.Tester.Email,
+			InviteType: string(entry.Tester.InviteType),
+			Groups:     entry.Tester.Groups,
+			Reason:     string(entry.Tester.Reason),
+			Detail:     entry.Tester.Detail,
+			Action:     entry.Action,
+			Status:     entry.status(),
+		}
+	}
+
+	return records
+}
+
+// WriteCSV writes the report as CSV, with a header row and one row per tester. Beta groups are
+// separated by semicolons, as in ReadRosterCSV.
+func (r *TesterPruneReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"time", "app_id", "tester_id", "email", "invite_type", "groups", "reason", "detail", "action", "status"}); err != nil {
+		return err
+	}
+
+	for _, record := range r.records() {
+		if err := writer.Write([]string{
+			record.Time,
+			record.AppID,
+			record.TesterID,
+			record.Email,
+			record.InviteType,
+			strings.Join(record.Groups, ";"),
+			record.Reason,
+			record.Detail,
+			record.Action,
+			record.Status,
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteJSON writes the report as a JSON array with one object per tester.
+func (r *TesterPruneReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r.records())
+}
+
+// PruneStaleTesters removes the testers of an app that TesterPruneRules find stale, and returns
+// the audit log of the prune. If dryRun is true, the stale testers are reported without being
+// removed. Errors of single testers do not stop the prune, and are reported in the
+// TesterPruneReport.
+func (s *TestflightService) PruneStaleTesters(ctx context.Context, rules TesterPruneRules, dryRun bool) (*TesterPruneReport, error) {
+	plan, err := s.PlanTesterPrune(ctx, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		report := &TesterPruneReport{AppID: plan.AppID, Time: time.Now(), Entries: make([]TesterPruneEntry, len(plan.Testers))}
+		for i, tester := range plan.Testers {
+			report.Entries[i] = TesterPruneEntry{Tester: tester, Action: plan.action()}
+		}
+
+		return report, nil
+	}
+
+	return s.ApplyTesterPrune(ctx, plan), nil
+}
+
+// testerPruneGroup is a beta group of the app being pruned, and the builds it gives access to.
+type testerPruneGroup struct {
+	name     string
+	internal bool
+	builds   []Build
+}
+
+// PlanTesterPrune finds the stale testers of an app according to rules, without changing
+// anything. It walks every beta group of the app and its builds, then every tester of the app
+// along with the builds assigned to them individually, which are included in the list of
+// testers. Builds are only requested per tester when a tester has more than the list includes.
+func (s *TestflightService) PlanTesterPrune(ctx context.Context, rules TesterPruneRules) (*TesterPrunePlan, error) {
+	groups, membership, err := s.testerPruneGroups(ctx, rules.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	testers, _, err := NewPager[BetaTester](s.client, func(ctx context.Context) (*BetaTestersResponse, *Response, error) {
+		return s.ListBetaTesters(ctx, &ListBetaTestersQuery{
+			FieldsBetaTesters: []string{"email", "inviteType", "builds"},
+			FieldsBuilds:      []string{"expired", "expirationDate", "version"},
+			FilterApps:        []string{rules.AppID},
+			Include:           []string{"builds"},
+			Limit:             200,
+			LimitBuilds:       []string{"50"},
+		})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[string]Build)
+
+	for _, item := range testers.Included {
+		if build := item.Build(); build != nil {
+			included[build.ID] = *build
+		}
+	}
+
+	plan := &TesterPrunePlan{AppID: rules.AppID, Delete: rules.Delete, Scanned: len(testers.Data)}
+	now := time.Now()
+
+	for _, tester := range testers.Data {
+		stale := StaleTester{TesterID: tester.ID}
+
+		if tester.Attributes != nil {
+			if tester.Attributes.Email != nil {
+				stale.Email = string(*tester.Attributes.Email)
+			}
+
+			if tester.Attributes.InviteType != nil {
+				stale.InviteType = *tester.Attributes.InviteType
+			}
+		}
+
+		if len(rules.InviteTypes) > 0 && !contains(rules.InviteTypes, stale.InviteType) {
+			continue
+		}
+
+		builds := make(map[string]Build)
+		kept := false
+
+		for _, groupID := range membership[tester.ID] {
+			group := groups[groupID]
+			stale.Groups = append(stale.Groups, group.name)
+			kept = kept || group.internal || contains(rules.KeepGroups, group.name)
+
+			for _, build := range group.builds {
+				builds[build.ID] = build
+			}
+		}
+
+		if kept {
+			continue
+		}
+
+		sort.Strings(stale.Groups)
+
+		// More builds can only keep a tester, so one the group builds already keep needs no more.
+		if len(builds) > 0 && !rules.stale(&StaleTester{}, builds, now) {
+			continue
+		}
+
+		individual, err := s.individualBuilds(ctx, tester, included)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, build := range individual {
+			builds[build.ID] = build
+		}
+
+		if rules.stale(&stale, builds, now) {
+			plan.Testers = append(plan.Testers, stale)
+		}
+	}
+
+	sort.SliceStable(plan.Testers, func(i, j int) bool { return plan.Testers[i].Email < plan.Testers[j].Email })
+
+	return plan, nil
+}
+
+// individualBuilds returns the builds assigned to a tester individually. They are taken from the
+// builds included with the tester, and only requested when some of them were left out.
+func (s *TestflightService) individualBuilds(ctx context.Context, tester BetaTester, included map[string]Build) ([]Build, error) {
+	if tester.Relationships != nil && tester.Relationships.Builds != nil {
+		relationship := tester.Relationships.Builds
+		builds := make([]Build, 0, len(relationship.Data))
+
+		for _, data := range relationship.Data {
+			if build, ok := included[data.ID]; ok {
+				builds = append(builds, build)
+			}
+		}
+
+		if len(builds) == len(relationship.Data) && (relationship.Meta == nil || relationship.Meta.Paging.Total <= len(builds)) {
+			return builds, nil
+		}
+	}
+
+	res, _, err := NewPager[Build](s.client, func(ctx context.Context) (*BuildsResponse, *Response, error) {
+		return s.ListBuildsIndividuallyAssignedToBetaTester(ctx, tester.ID, &ListBuildsIndividuallyAssignedToBetaTesterQuery{
+			FieldsBuilds: []string{"expired", "expirationDate", "version"},
+			Limit:        200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
+
+// stale reports whether a tester who can reach builds is stale, and sets the reason.
+func (r TesterPruneRules) stale(tester *StaleTester, builds map[string]Build, now time.Time) bool {
+	if len(builds) == 0 {
+		if !r.NoAccess {
+			return false
+		}
+
+		tester.Reason = TesterPruneNoAccess
+		if len(tester.Groups) == 0 {
+			tester.Detail = "in no beta group and has no builds"
+		} else {
+			tester.Detail = "beta groups have no builds"
+		}
+
+		return true
+	}
+
+	if !r.ExpiredBuilds {
+		return false
+	}
+
+	var (
+		last    time.Time
+		version string
+	)
+
+	for _, build := range builds {
+		expiredAt, ok := buildExpiredAt(build, now)
+		if !ok {
+			return false
+		}
+
+		if expiredAt.After(last) {
+			last = expiredAt
+
+			if build.Attributes != nil {
+				version = stringValue(build.Attributes.Version)
+			}
+		}
+	}
+
+	if now.Sub(last) < r.ExpiredFor {
+		return false
+	}
+
+	tester.Reason = TesterPruneExpiredBuilds
+	tester.Detail = fmt.Sprintf("last build %s expired on %s", version, last.Format("2006-01-02"))
+
+	return true
+}
+
+// buildExpiredAt returns when a build expired, and whether it did. Builds expired ahead of their
+// expiration date are treated as having expired now.
+func buildExpiredAt(build Build, now time.Time) (time.Time, bool) {
+	if build.Attributes == nil {
+		return time.Time{}, false
+	}
+
+	var expiration *time.Time
+	if build.Attributes.ExpirationDate != nil {
+		expiration = &build.Attributes.ExpirationDate.Time
+	}
+
+	switch {
+	case expiration != nil && !expiration.After(now):
+		return *expiration, true
+	case build.Attributes.Expired != nil && *build.Attributes.Expired:
+		return now, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// testerPruneGroups returns the beta groups of the app by ID, and the IDs of the beta groups
+// each tester belongs to.
+func (s *TestflightService) testerPruneGroups(ctx context.Context, appID string) (map[string]*testerPruneGroup, map[string][]string, error) {
+	res, _, err := NewPager[BetaGroup](s.client, func(ctx context.Context) (*BetaGroupsResponse, *Response, error) {
+		return s.ListBetaGroupsForApp(ctx, appID, &ListBetaGroupsForAppQuery{
+			FieldsBetaGroups: []string{"name", "isInternalGroup"},
+			Limit:            200,
+		})
+	}).All(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := make(map[string]*testerPruneGroup, len(res.Data))
+	membership := make(map[string][]string)
+
+	for _, group := range res.Data {
+		current := &testerPruneGroup{}
+		if group.Attributes != nil {
+			current.name = stringValue(group.Attributes.Name)
+			current.internal = group.Attributes.IsInternalGroup != nil && *group.Attributes.IsInternalGroup
+		}
+
+		groups[group.ID] = current
+
+		builds, _, err := NewPager[Build](s.client, func(ctx context.Context) (*BuildsResponse, *Response, error) {
+			return s.ListBuildsForBetaGroup(ctx, group.ID, &ListBuildsForBetaGroupQuery{
+				FieldsBuilds: []string{"expired", "expirationDate", "version"},
+				Limit:        200,
+			})
+		}).All(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		current.builds = builds.Data
+
+		testers, _, err := NewPager[RelationshipData](s.client, func(ctx context.Context) (*BetaGroupBetaTestersLinkagesResponse, *Response, error) {
+			return s.ListBetaTesterIDsForBetaGroup(ctx, group.ID, &ListBetaTesterIDsForBetaGroupQuery{Limit: 200})
+		}).All(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, tester := range testers.Data {
+			membership[tester.ID] = append(membership[tester.ID], group.ID)
+		}
+	}
+
+	return groups, membership, nil
+}
+
+// ApplyTesterPrune applies a plan returned by PlanTesterPrune, one tester at a time. Errors do
+// not stop the prune, and are reported with the tester they belong to.
+func (s *TestflightService) ApplyTesterPrune(ctx context.Context, plan *TesterPrunePlan) *TesterPruneReport {
+	report := &TesterPruneReport{AppID: plan.AppID, Time: time.Now(), Entries: make([]TesterPruneEntry, len(plan.Testers))}
+
+	for i, tester := range plan.Testers {
+		var err error
+
+		if plan.Delete {
+			_, err = s.DeleteBetaTester(ctx, tester.TesterID)
+		} else {
+			_, err = s.RemoveSingleBetaTesterAccessApps(ctx, tester.TesterID, []string{plan.AppID})
+		}
+
+		report.Entries[i] = TesterPruneEntry{Tester: tester, Action: plan.action(), Applied: err == nil, Err: err}
+	}
+
+	return report
+}
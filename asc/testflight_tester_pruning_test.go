@@ -0,0 +1,179 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockTesterPruneServer serves app "a1", whose beta groups are "Internal" (g1) with a build
+// that has not expired, "Friends" (g2) with a build that expired in 2020 and "Empty" (g3) with
+// no builds, and "Beta" (g4) with a build that has not expired. Build b3, which has not expired,
+// is assigned individually to t4, where it is included in the list of testers, and to t6, where
+// it must be requested separately. The builds of t7 are never requested, since g4 keeps them.
+func newMockTesterPruneServer(t *testing.T) (*Client, *routeServer) {
+	t.Helper()
+
+	testers := map[string]string{
+		"t1": "alice@example.com",
+		"t2": "bob@example.com",
+		"t3": "carol@example.com",
+		"t4": "dave@example.com",
+		"t5": "erin@example.com",
+		"t6": "frank@example.com",
+		"t7": "grace@example.com",
+	}
+
+	return newRouteServer(t, map[string]http.HandlerFunc{
+		"GET /apps/a1/betaGroups": respond(`{"data":[
+			{"id":"g1","type":"betaGroups","attributes":{"name":"Internal","isInternalGroup":true}},
+			{"id":"g2","type":"betaGroups","attributes":{"name":"Friends","isInternalGroup":false}},
+			{"id":"g3","type":"betaGroups","attributes":{"name":"Empty","isInternalGroup":false}},
+			{"id":"g4","type":"betaGroups","attributes":{"name":"Beta","isInternalGroup":false}}
+		]}`),
+		"GET /betaGroups/g1/builds":                    respond(`{"data":[{"id":"b1","type":"builds","attributes":{"version":"1","expired":false,"expirationDate":"2999-01-01T00:00:00Z"}}]}`),
+		"GET /betaGroups/g2/builds":                    respond(`{"data":[{"id":"b2","type":"builds","attributes":{"version":"2","expired":true,"expirationDate":"2020-01-01T00:00:00Z"}}]}`),
+		"GET /betaGroups/g3/builds":                    respond(`{"data":[]}`),
+		"GET /betaGroups/g4/builds":                    respond(`{"data":[{"id":"b4","type":"builds","attributes":{"version":"4","expired":false,"expirationDate":"2999-01-01T00:00:00Z"}}]}`),
+		"GET /betaGroups/g1/relationships/betaTesters": respond(`{"data":[{"id":"t1","type":"betaTesters"}]}`),
+		"GET /betaGroups/g2/relationships/betaTesters": respond(`{"data":[{"id":"t1","type":"betaTesters"},{"id":"t2","type":"betaTesters"},{"id":"t6","type":"betaTesters"}]}`),
+		"GET /betaGroups/g3/relationships/betaTesters": respond(`{"data":[{"id":"t3","type":"betaTesters"}]}`),
+		"GET /betaGroups/g4/relationships/betaTesters": respond(`{"data":[{"id":"t7","type":"betaTesters"}]}`),
+		"GET /betaTesters": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "a1", r.URL.Query().Get("filter[apps]"))
+			assert.Equal(t, "builds", r.URL.Query().Get("include"))
+
+			builds := map[string]string{
+				"t4": `{"data":[{"id":"b3","type":"builds"}]}`,
+				"t6": `{"data":[],"meta":{"paging":{"total":1,"limit":0}}}`,
+				"t7": `{"data":[],"meta":{"paging":{"total":1,"limit":0}}}`,
+			}
+
+			data := make([]string, 0, len(testers))
+			for _, id := range []string{"t1", "t2", "t3", "t4", "t5", "t6", "t7"} {
+				inviteType := BetaInviteTypeEmail
+				if id == "t2" {
+					inviteType = BetaInviteTypePublicLink
+				}
+
+				relationship, ok := builds[id]
+				if !ok {
+					relationship = `{"data":[]}`
+				}
+
+				data = append(data, fmt.Sprintf(`{"id":%q,"type":"betaTesters","attributes":{"email":%q,"inviteType":%q},"relationships":{"builds":%s}}`, id, testers[id], inviteType, relationship))
+			}
+
+			fmt.Fprintf(w, `{"data":[%s],"included":[{"id":"b3","type":"builds","attributes":{"version":"3","expired":false,"expirationDate":"2999-01-01T00:00:00Z"}}]}`, strings.Join(data, ","))
+		},
+		"GET /betaTesters/t6/builds":                respond(`{"data":[{"id":"b3","type":"builds","attributes":{"version":"3","expired":false,"expirationDate":"2999-01-01T00:00:00Z"}}]}`),
+		"DELETE /betaTesters/t2/relationships/apps": noContent,
+		"DELETE /betaTesters/t3/relationships/apps": noContent,
+		"DELETE /betaTesters/t5/relationships/apps": notFound,
+	})
+}
+
+func TestPlanTesterPrune(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockTesterPruneServer(t)
+
+	plan, err := client.TestFlight.PlanTesterPrune(context.Background(), TesterPruneRules{
+		AppID:         "a1",
+		NoAccess:      true,
+		ExpiredBuilds: true,
+		ExpiredFor:    30 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, plan.Scanned)
+	assert.Equal(t, []StaleTester{
+		{TesterID: "t2", Email: "bob@example.com", InviteType: BetaInviteTypePublicLink, Groups: []string{"Friends"}, Reason: TesterPruneExpiredBuilds, Detail: "last build 2 expired on 2020-01-01"},
+		{TesterID: "t3", Email: "carol@example.com", InviteType: BetaInviteTypeEmail, Groups: []string{"Empty"}, Reason: TesterPruneNoAccess, Detail: "beta groups have no builds"},
+		{TesterID: "t5", Email: "erin@example.com", InviteType: BetaInviteTypeEmail, Reason: TesterPruneNoAccess, Detail: "in no beta group and has no builds"},
+	}, plan.Testers)
+	assert.Equal(t, "remove bob@example.com (t2): expired builds, last build 2 expired on 2020-01-01\n"+
+		"remove carol@example.com (t3): no access, beta groups have no builds\n"+
+		"remove erin@example.com (t5): no access, in no beta group and has no builds\n", plan.String())
+	assert.Empty(t, server.changes())
+
+	plan, err = client.TestFlight.PlanTesterPrune(context.Background(), TesterPruneRules{
+		AppID:         "a1",
+		NoAccess:      true,
+		ExpiredBuilds: true,
+		InviteTypes:   []BetaInviteType{BetaInviteTypeEmail},
+		KeepGroups:    []string{"Empty"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, plan.Testers, 1)
+	assert.Equal(t, "t5", plan.Testers[0].TesterID)
+}
+
+func TestPruneStaleTesters(t *testing.T) {
+	t.Parallel()
+
+	client, server := newMockTesterPruneServer(t)
+	rules := TesterPruneRules{AppID: "a1", NoAccess: true, ExpiredBuilds: true}
+
+	report, err := client.TestFlight.PruneStaleTesters(context.Background(), rules, true)
+	assert.NoError(t, err)
+	assert.Len(t, report.Entries, 3)
+	assert.NoError(t, report.Err())
+	assert.Empty(t, server.changes())
+
+	report, err = client.TestFlight.PruneStaleTesters(context.Background(), rules, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE /betaTesters/t2/relationships/apps",
+		"DELETE /betaTesters/t3/relationships/apps",
+		"DELETE /betaTesters/t5/relationships/apps",
+	}, server.changes())
+	assert.Len(t, report.Failed(), 1)
+	assert.ErrorIs(t, report.Err(), ErrNotFound)
+	assert.True(t, report.Entries[0].Applied)
+	assert.False(t, report.Entries[2].Applied)
+
+	report.Time = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var b bytes.Buffer
+	assert.NoError(t, report.WriteCSV(&b))
+
+	rows, err := csv.NewReader(&b).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, []string{"time", "app_id", "tester_id", "email", "invite_type", "groups", "reason", "detail", "action", "status"}, rows[0])
+	assert.Equal(t, []string{"2026-01-02T03:04:05Z", "a1", "t3", "carol@example.com", "EMAIL", "Empty", "no access", "beta groups have no builds", "remove", "ok"}, rows[2])
+
+	b.Reset()
+	assert.NoError(t, report.WriteJSON(&b))
+	assert.Contains(t, b.String(), `"tester_id": "t2"`)
+	assert.Contains(t, b.String(), `"groups": [
+      "Friends"
+    ]`)
+}
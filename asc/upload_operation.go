@@ -21,9 +21,9 @@ along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
 package asc
 
 import (
-	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"sync"
@@ -67,38 +67,78 @@ func (e UploadOperationError) Error() string {
 	return e.Err.Error()
 }
 
-// chunk returns the bytes in the file from the given offset and with the given length.
-func (op *UploadOperation) chunk(f io.ReadSeeker) (io.Reader, error) {
-	if op.Offset == nil || op.Length == nil {
-		return nil, ErrMissingChunkBounds
-	}
+// Unwrap returns the error that caused the operation to fail.
+func (e UploadOperationError) Unwrap() error {
+	return e.Err
+}
 
-	_, err := f.Seek(int64(*op.Offset), 0)
-	if err != nil {
-		return nil, err
+// UploadError is returned by Client.Upload when one or more operations fail. The failed
+// operations can be passed to Client.Upload again to resume the upload.
+type UploadError struct {
+	// Errors holds every operation that failed, in the order the operations were given.
+	Errors []UploadOperationError
+	// Total is the number of operations that were attempted.
+	Total int
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("%d of %d upload operations failed, first error: %v", len(e.Errors), e.Total, e.Errors[0].Err)
+}
+
+// Unwrap returns the error of the first failed operation.
+func (e *UploadError) Unwrap() error {
+	return e.Errors[0]
+}
+
+// Operations returns the operations that failed, so they can be retried.
+func (e *UploadError) Operations() []UploadOperation {
+	ops := make([]UploadOperation, len(e.Errors))
+	for i, err := range e.Errors {
+		ops[i] = err.Operation
 	}
 
-	data := make([]byte, *op.Length)
+	return ops
+}
 
-	_, err = f.Read(data)
-	if err != nil {
-		return nil, err
+// UploadOptions configures how Client.UploadWithOptions performs an upload.
+type UploadOptions struct {
+	// Concurrency is the maximum number of operations uploaded at the same time. Defaults to 4.
+	Concurrency int
+	// RetryPolicy determines how each operation is retried. Defaults to the client's RetryPolicy.
+	RetryPolicy *RetryPolicy
+	// OnProgress, if set, is called after each operation completes with the number of bytes
+	// uploaded so far and the total number of bytes across all operations. Calls are serialized.
+	OnProgress func(sent int64, total int64)
+}
+
+const defaultUploadConcurrency = 4
+
+// chunk returns a reader over the bytes in the file from the given offset and with the given length.
+func (op *UploadOperation) chunk(f io.ReaderAt) (*io.SectionReader, error) {
+	if op.Offset == nil || op.Length == nil {
+		return nil, ErrMissingChunkBounds
 	}
 
-	return bytes.NewBuffer(data), nil
+	return io.NewSectionReader(f, int64(*op.Offset), int64(*op.Length)), nil
 }
 
-// request creates a new http.request instance from the given UploadOperation and buffer.
-func (op *UploadOperation) request(ctx context.Context, data io.Reader) (*http.Request, error) {
+// request creates a new http.request instance from the given UploadOperation and chunk.
+// The request body can be rewound, so the request can be retried.
+func (op *UploadOperation) request(ctx context.Context, chunk *io.SectionReader) (*http.Request, error) {
 	if op.Method == nil || op.URL == nil {
 		return nil, ErrMissingUploadDestination
 	}
 
-	req, err := http.NewRequestWithContext(ctx, *op.Method, *op.URL, data)
+	req, err := http.NewRequestWithContext(ctx, *op.Method, *op.URL, io.NewSectionReader(chunk, 0, chunk.Size()))
 	if err != nil {
 		return nil, err
 	}
 
+	req.ContentLength = chunk.Size()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(chunk, 0, chunk.Size())), nil
+	}
+
 	if op.RequestHeaders != nil {
 		for _, h := range op.RequestHeaders {
 			if h.Name == nil || h.Value == nil {
@@ -112,58 +152,112 @@ func (op *UploadOperation) request(ctx context.Context, data io.Reader) (*http.R
 	return req, nil
 }
 
-// Upload takes a file path and concurrently uploads each part of the file to App Store Connect.
-func (c *Client) Upload(ctx context.Context, ops []UploadOperation, file io.ReadSeeker) error {
-	var wg sync.WaitGroup
+// Upload concurrently uploads each part of the file to App Store Connect, as described by the
+// given operations. If any operation fails, an *UploadError listing every failed operation is
+// returned, and those operations can be passed to Upload again to resume.
+func (c *Client) Upload(ctx context.Context, ops []UploadOperation, file io.ReaderAt) error {
+	return c.UploadWithOptions(ctx, ops, file, nil)
+}
 
-	errs := make(chan UploadOperationError)
+// UploadWithOptions uploads each part of the file like Upload, configured with the given options.
+// If opts is nil, the options set with SetUploadOptions are used.
+func (c *Client) UploadWithOptions(ctx context.Context, ops []UploadOperation, file io.ReaderAt, opts *UploadOptions) error {
+	if opts == nil {
+		opts = c.uploadOptions
+	}
 
-	for i, operation := range ops {
-		chunk, err := operation.chunk(file)
-		if err != nil {
-			errs <- UploadOperationError{
-				Operation: operation,
-				Err:       err,
-			}
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultUploadConcurrency
+	}
+
+	policy := opts.RetryPolicy
+	if policy == nil {
+		policy = c.retryPolicy
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		sent   int64
+		total  int64
+		failed = make([]*UploadOperationError, len(ops))
+		sem    = make(chan struct{}, concurrency)
+	)
+
+	for _, op := range ops {
+		if op.Length != nil {
+			total += int64(*op.Length)
+		}
+	}
+
+	for i, op := range ops {
+		select {
+		case <-ctx.Done():
+			failed[i] = &UploadOperationError{Operation: op, Err: ctx.Err()}
 
 			continue
+		case sem <- struct{}{}:
 		}
 
 		wg.Add(1)
 
-		go c.uploadChunk(ctx, ops[i], chunk, errs, &wg)
+		go func(i int, op UploadOperation) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := c.uploadChunk(ctx, op, file, policy)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				failed[i] = &UploadOperationError{Operation: op, Err: err}
+
+				return
+			}
+
+			sent += int64(*op.Length)
+
+			if opts.OnProgress != nil {
+				opts.OnProgress(sent, total)
+			}
+		}(i, op)
 	}
 
-	go func() {
-		wg.Wait()
-		close(errs)
-	}()
+	wg.Wait()
 
-	for err := range errs {
-		return err
+	uploadErr := &UploadError{Total: len(ops)}
+
+	for _, err := range failed {
+		if err != nil {
+			uploadErr.Errors = append(uploadErr.Errors, *err)
+		}
+	}
+
+	if len(uploadErr.Errors) > 0 {
+		return uploadErr
 	}
 
 	return nil
 }
 
-func (c *Client) uploadChunk(ctx context.Context, op UploadOperation, chunk io.Reader, errs chan<- UploadOperationError, wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	req, err := op.request(ctx, chunk)
+func (c *Client) uploadChunk(ctx context.Context, op UploadOperation, file io.ReaderAt, policy *RetryPolicy) error {
+	chunk, err := op.chunk(file)
 	if err != nil {
-		errs <- UploadOperationError{
-			Operation: op,
-			Err:       err,
-		}
-
-		return
+		return err
 	}
 
-	_, err = c.do(ctx, req, nil)
+	req, err := op.request(ctx, chunk)
 	if err != nil {
-		errs <- UploadOperationError{
-			Operation: op,
-			Err:       err,
-		}
+		return err
 	}
+
+	_, err = c.doWithRetry(ctx, req, nil, policy)
+
+	return err
 }
@@ -24,11 +24,16 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -193,6 +198,158 @@ func TestUploadOperationUploadError_InvalidOperation(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestUploadMissingChunkBounds(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer("", http.StatusOK, false)
+	defer server.Close()
+
+	operations := []UploadOperation{
+		{
+			URL:    String(client.baseURL.String()),
+			Method: String("PUT"),
+		},
+		{
+			URL:    String(client.baseURL.String()),
+			Offset: Int(0),
+			Length: Int(4),
+			Method: String("PUT"),
+		},
+	}
+
+	err := client.Upload(context.Background(), operations, bytes.NewReader(make([]byte, 4)))
+
+	var uploadErr *UploadError
+
+	assert.True(t, errors.As(err, &uploadErr))
+	assert.Len(t, uploadErr.Errors, 1)
+	assert.Equal(t, 2, uploadErr.Total)
+	assert.ErrorIs(t, err, ErrMissingChunkBounds)
+	assert.Equal(t, []UploadOperation{operations[0]}, uploadErr.Operations())
+	assert.Contains(t, err.Error(), "1 of 2 upload operations failed")
+}
+
+func TestUploadWithOptionsReportsFailuresAndProgress(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+		received = make(map[string][]byte)
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		inFlight--
+		received[r.URL.Path] = body
+		mu.Unlock()
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, "{}")
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client())
+	contents := []byte("0123456789abcdefghij")
+
+	operations := make([]UploadOperation, 0, 5)
+	for i := 0; i < 4; i++ {
+		operations = append(operations, UploadOperation{
+			URL:    String(fmt.Sprintf("%s/part%d", server.URL, i)),
+			Offset: Int(i * 5),
+			Length: Int(5),
+			Method: String("PUT"),
+		})
+	}
+
+	operations = append(operations, UploadOperation{
+		URL:    String(server.URL + "/fail"),
+		Offset: Int(0),
+		Length: Int(5),
+		Method: String("PUT"),
+	})
+
+	var progress [][2]int64
+
+	err := client.UploadWithOptions(context.Background(), operations, bytes.NewReader(contents), &UploadOptions{
+		Concurrency: 2,
+		OnProgress: func(sent int64, total int64) {
+			progress = append(progress, [2]int64{sent, total})
+		},
+	})
+
+	var uploadErr *UploadError
+
+	assert.True(t, errors.As(err, &uploadErr))
+	assert.Equal(t, []UploadOperation{operations[4]}, uploadErr.Operations())
+	assert.LessOrEqual(t, maxSeen, 2)
+	assert.Equal(t, []byte("abcde"), received["/part2"])
+	assert.Len(t, progress, 4)
+	assert.Equal(t, [2]int64{20, 25}, progress[3])
+}
+
+func TestUploadRetriesOperation(t *testing.T) {
+	t.Parallel()
+
+	client, server, calls := newFlakyServer(1, http.StatusServiceUnavailable, nil)
+	defer server.Close()
+
+	operations := []UploadOperation{
+		{
+			URL:    String(client.baseURL.String()),
+			Offset: Int(0),
+			Length: Int(4),
+			Method: String("PUT"),
+		},
+	}
+
+	err := client.Upload(context.Background(), operations, bytes.NewReader([]byte("data")))
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
+
+func TestUploadContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	client, server := newServer("", http.StatusOK, false)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	operations := []UploadOperation{
+		{
+			URL:    String(client.baseURL.String()),
+			Offset: Int(0),
+			Length: Int(4),
+			Method: String("PUT"),
+		},
+	}
+
+	err := client.Upload(ctx, operations, bytes.NewReader([]byte("data")))
+
+	var uploadErr *UploadError
+
+	assert.True(t, errors.As(err, &uploadErr))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, uploadErr.Operations(), 1)
+}
+
 // rmFile closes an open descriptor.
 func rmFile(f *os.File) {
 	if err := os.Remove(f.Name()); err != nil {
@@ -0,0 +1,303 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Field limits enforced by App Store Connect.
+const (
+	maxNameLength            = 30
+	maxSubtitleLength        = 30
+	maxKeywordsBytes         = 100
+	maxPromotionalTextLength = 170
+	maxDescriptionLength     = 4000
+	maxWhatsNewLength        = 4000
+	maxReviewNotesLength     = 4000
+)
+
+// Codes of a Violation.
+const (
+	// ViolationRequired is the code of a violation for a required field that is missing or empty.
+	ViolationRequired = "REQUIRED"
+	// ViolationTooLong is the code of a violation for a field that exceeds its length limit.
+	ViolationTooLong = "TOO_LONG"
+	// ViolationInvalidURL is the code of a violation for a field that is not an absolute
+	// http or https URL.
+	ViolationInvalidURL = "INVALID_URL"
+	// ViolationInvalidEmail is the code of a violation for a field that is not an email address.
+	ViolationInvalidEmail = "INVALID_EMAIL"
+	// ViolationInvalidDate is the code of a violation for a date outside of the range App Store
+	// Connect accepts.
+	ViolationInvalidDate = "INVALID_DATE"
+	// ViolationDuplicate is the code of a violation for a value that must be unique, but appears
+	// more than once.
+	ViolationDuplicate = "DUPLICATE"
+)
+
+// attributesPointer is the JSON pointer of the attributes of a request body.
+const attributesPointer = "/data/attributes"
+
+// Violation is a single problem found when validating a request locally.
+type Violation struct {
+	// Pointer is a JSON pointer to the invalid field. For request attributes, it has the same
+	// form as the ErrorSource.Pointer App Store Connect returns, such as "/data/attributes/name".
+	Pointer string
+	// Code is one of the Violation constants, such as ViolationTooLong.
+	Code string
+	// Detail is a human-readable explanation of the violation.
+	Detail string
+}
+
+// ValidationError holds every Violation found by one of the Validate functions.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	details := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		details[i] = fmt.Sprintf("%s: %s", violation.Pointer, violation.Detail)
+	}
+
+	return fmt.Sprintf("%d validation errors: %s", len(e.Violations), strings.Join(details, "; "))
+}
+
+// ValidateAppStoreVersionLocalization checks the attributes of an App Store version
+// localization update against the limits of App Store Connect. It returns a *ValidationError
+// if any are violated. Fields that are nil are not checked.
+func ValidateAppStoreVersionLocalization(attributes *AppStoreVersionLocalizationUpdateRequestAttributes) error {
+	if attributes == nil {
+		return nil
+	}
+
+	v := validator{prefix: attributesPointer}
+	v.versionLocalization(attributes.Description, attributes.Keywords, attributes.MarketingURL, attributes.PromotionalText, attributes.SupportURL, attributes.WhatsNew)
+
+	return v.err()
+}
+
+// ValidateAppInfoLocalization checks the attributes of an app info localization update against
+// the limits of App Store Connect. It returns a *ValidationError if any are violated. Fields that
+// are nil are not checked.
+func ValidateAppInfoLocalization(attributes *AppInfoLocalizationUpdateRequestAttributes) error {
+	if attributes == nil {
+		return nil
+	}
+
+	v := validator{prefix: attributesPointer}
+	v.infoLocalization(attributes.Name, attributes.Subtitle, attributes.PrivacyPolicyURL)
+
+	return v.err()
+}
+
+// ValidateAppStoreReviewDetail checks the attributes of an App Store review detail before it is
+// submitted for review. The contact fields are required, and the demo account fields are
+// required when DemoAccountRequired is true. It returns a *ValidationError if any are violated.
+func ValidateAppStoreReviewDetail(attributes *AppStoreReviewDetailUpdateRequestAttributes) error {
+	if attributes == nil {
+		attributes = &AppStoreReviewDetailUpdateRequestAttributes{}
+	}
+
+	v := validator{prefix: attributesPointer}
+	v.required("contactFirstName", attributes.ContactFirstName)
+	v.required("contactLastName", attributes.ContactLastName)
+	v.required("contactPhone", attributes.ContactPhone)
+
+	if v.required("contactEmail", attributes.ContactEmail) {
+		v.email("contactEmail", attributes.ContactEmail)
+	}
+
+	if attributes.DemoAccountRequired != nil && *attributes.DemoAccountRequired {
+		v.required("demoAccountName", attributes.DemoAccountName)
+		v.required("demoAccountPassword", attributes.DemoAccountPassword)
+	}
+
+	v.maxLength("notes", attributes.Notes, maxReviewNotesLength)
+
+	return v.err()
+}
+
+// Validate checks every locale of the document against the limits of App Store Connect. The
+// pointers of the violations are relative to the document, such as "/locales/en-US/name".
+func (m *Metadata) Validate() error {
+	locales := make([]string, 0, len(m.Locales))
+	for locale := range m.Locales {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+
+	var violations []Violation
+
+	for _, locale := range locales {
+		metadata := m.Locales[locale]
+		v := validator{prefix: "/locales/" + escapePointer(locale)}
+		v.infoLocalization(metadata.Name, metadata.Subtitle, metadata.PrivacyPolicyURL)
+		v.versionLocalization(metadata.Description, metadata.Keywords, metadata.MarketingURL, metadata.PromotionalText, metadata.SupportURL, metadata.WhatsNew)
+		violations = append(violations, v.violations...)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Violations: violations}
+}
+
+// Validate checks that every tester of the roster has a valid email address, appearing only
+// once, and groups with names. The pointers of the violations are relative to the roster, such
+// as "/testers/3/email".
+func (r *Roster) Validate() error {
+	var violations []Violation
+
+	seen := make(map[string]int, len(r.Testers))
+
+	for i, tester := range r.Testers {
+		v := validator{prefix: fmt.Sprintf("/testers/%d", i)}
+		email := tester.Email
+
+		if v.required("email", &email) {
+			v.email("email", &email)
+
+			key := strings.ToLower(email)
+			if first, ok := seen[key]; ok {
+				v.add("email", ViolationDuplicate, "email %s is already used by tester %d", email, first)
+			} else {
+				seen[key] = i
+			}
+		}
+
+		groups := validator{prefix: v.prefix + "/groups"}
+
+		for j, group := range tester.Groups {
+			if strings.TrimSpace(group) == "" {
+				groups.add(strconv.Itoa(j), ViolationRequired, "group name is required")
+			}
+		}
+
+		violations = append(violations, v.violations...)
+		violations = append(violations, groups.violations...)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Violations: violations}
+}
+
+// validator collects the violations of the fields of one object.
+type validator struct {
+	prefix     string
+	violations []Violation
+}
+
+func (v *validator) versionLocalization(description, keywords, marketingURL, promotionalText, supportURL, whatsNew *string) {
+	v.maxLength("description", description, maxDescriptionLength)
+	v.maxBytes("keywords", keywords, maxKeywordsBytes)
+	v.url("marketingUrl", marketingURL)
+	v.maxLength("promotionalText", promotionalText, maxPromotionalTextLength)
+	v.url("supportUrl", supportURL)
+	v.maxLength("whatsNew", whatsNew, maxWhatsNewLength)
+}
+
+func (v *validator) infoLocalization(name, subtitle, privacyPolicyURL *string) {
+	v.maxLength("name", name, maxNameLength)
+	v.maxLength("subtitle", subtitle, maxSubtitleLength)
+	v.url("privacyPolicyUrl", privacyPolicyURL)
+}
+
+func (v *validator) add(field string, code string, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{
+		Pointer: v.prefix + "/" + escapePointer(field),
+		Code:    code,
+		Detail:  fmt.Sprintf(format, args...),
+	})
+}
+
+// required reports whether the field is set to a non-blank value.
+func (v *validator) required(field string, value *string) bool {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		v.add(field, ViolationRequired, "%s is required", field)
+
+		return false
+	}
+
+	return true
+}
+
+func (v *validator) maxLength(field string, value *string, limit int) {
+	if value == nil {
+		return
+	}
+
+	if length := utf8.RuneCountInString(*value); length > limit {
+		v.add(field, ViolationTooLong, "%s is %d characters long, but the limit is %d", field, length, limit)
+	}
+}
+
+func (v *validator) maxBytes(field string, value *string, limit int) {
+	if value == nil {
+		return
+	}
+
+	if length := len(*value); length > limit {
+		v.add(field, ViolationTooLong, "%s is %d bytes long, but the limit is %d", field, length, limit)
+	}
+}
+
+// url checks that a field is empty or an absolute http or https URL.
+func (v *validator) url(field string, value *string) {
+	if value == nil || *value == "" {
+		return
+	}
+
+	u, err := url.Parse(*value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, ViolationInvalidURL, "%s is not an absolute http or https URL", field)
+	}
+}
+
+func (v *validator) email(field string, value *string) {
+	if value != nil && !emailRegex.MatchString(*value) {
+		v.add(field, ViolationInvalidEmail, "%s is not a valid email address", field)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Violations: v.violations}
+}
+
+// escapePointer escapes a reference token of a JSON pointer, as described in RFC 6901.
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
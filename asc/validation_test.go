@@ -0,0 +1,138 @@
+/**
+Copyright (C) 2020 Aaron Sky.
+
+This file is part of asc-go, a package for working with Apple's
+App Store Connect API.
+
+asc-go is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+asc-go is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with asc-go.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package asc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func violationsOf(t *testing.T, err error) []Violation {
+	t.Helper()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+
+	return validationErr.Violations
+}
+
+func TestValidateAppStoreVersionLocalization(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateAppStoreVersionLocalization(nil))
+	assert.NoError(t, ValidateAppStoreVersionLocalization(&AppStoreVersionLocalizationUpdateRequestAttributes{
+		Description:  String(strings.Repeat("é", 4000)),
+		Keywords:     String(strings.Repeat("k", 100)),
+		MarketingURL: String(""),
+		SupportURL:   String("https://example.com/support"),
+	}))
+
+	err := ValidateAppStoreVersionLocalization(&AppStoreVersionLocalizationUpdateRequestAttributes{
+		Description:     String(strings.Repeat("d", 4001)),
+		Keywords:        String(strings.Repeat("é", 51)),
+		MarketingURL:    String("example.com"),
+		PromotionalText: String(strings.Repeat("p", 171)),
+		SupportURL:      String("ftp://example.com"),
+		WhatsNew:        String(strings.Repeat("w", 4001)),
+	})
+
+	violations := violationsOf(t, err)
+	assert.Len(t, violations, 6)
+	assert.Equal(t, Violation{
+		Pointer: "/data/attributes/keywords",
+		Code:    ViolationTooLong,
+		Detail:  "keywords is 102 bytes long, but the limit is 100",
+	}, violations[1])
+	assert.Equal(t, "/data/attributes/marketingUrl", violations[2].Pointer)
+	assert.Equal(t, ViolationInvalidURL, violations[2].Code)
+	assert.Contains(t, err.Error(), "6 validation errors: /data/attributes/description")
+}
+
+func TestValidateAppInfoLocalization(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateAppInfoLocalization(nil))
+
+	err := ValidateAppInfoLocalization(&AppInfoLocalizationUpdateRequestAttributes{
+		Name:             String(strings.Repeat("n", 31)),
+		Subtitle:         String(strings.Repeat("s", 30)),
+		PrivacyPolicyURL: String("http://"),
+	})
+
+	violations := violationsOf(t, err)
+	assert.Len(t, violations, 2)
+	assert.Equal(t, "/data/attributes/name", violations[0].Pointer)
+	assert.Equal(t, "/data/attributes/privacyPolicyUrl", violations[1].Pointer)
+}
+
+func TestValidateAppStoreReviewDetail(t *testing.T) {
+	t.Parallel()
+
+	violations := violationsOf(t, ValidateAppStoreReviewDetail(nil))
+	assert.Len(t, violations, 4)
+	assert.Equal(t, ViolationRequired, violations[0].Code)
+
+	err := ValidateAppStoreReviewDetail(&AppStoreReviewDetailUpdateRequestAttributes{
+		ContactFirstName:    String("Jane"),
+		ContactLastName:     String("Doe"),
+		ContactPhone:        String("+1 555 0100"),
+		ContactEmail:        String("jane"),
+		DemoAccountRequired: Bool(true),
+		DemoAccountName:     String(" "),
+		Notes:               String(strings.Repeat("n", 4001)),
+	})
+
+	violations = violationsOf(t, err)
+	assert.Len(t, violations, 4)
+	assert.Equal(t, ViolationInvalidEmail, violations[0].Code)
+	assert.Equal(t, "/data/attributes/demoAccountName", violations[1].Pointer)
+	assert.Equal(t, "/data/attributes/demoAccountPassword", violations[2].Pointer)
+	assert.Equal(t, "/data/attributes/notes", violations[3].Pointer)
+
+	assert.NoError(t, ValidateAppStoreReviewDetail(&AppStoreReviewDetailUpdateRequestAttributes{
+		ContactFirstName: String("Jane"),
+		ContactLastName:  String("Doe"),
+		ContactPhone:     String("+1 555 0100"),
+		ContactEmail:     String("jane@example.com"),
+	}))
+}
+
+func TestMetadataValidate(t *testing.T) {
+	t.Parallel()
+
+	metadata := &Metadata{Locales: map[string]LocaleMetadata{
+		"en-US":   {Name: String("App"), Keywords: String("a,b")},
+		"zh-Hans": {Subtitle: String(strings.Repeat("字", 31))},
+		"a/b":     {SupportURL: String("nope")},
+	}}
+
+	violations := violationsOf(t, metadata.Validate())
+	assert.Len(t, violations, 2)
+	assert.Equal(t, "/locales/a~1b/supportUrl", violations[0].Pointer)
+	assert.Equal(t, "/locales/zh-Hans/subtitle", violations[1].Pointer)
+
+	assert.NoError(t, (&Metadata{}).Validate())
+}
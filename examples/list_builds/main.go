@@ -52,28 +52,15 @@ func main() {
 		log.Fatalf("%s", err)
 	}
 
-	var cursor string
-	params := asc.ListBuildsQuery{
-		FilterApp: []string{app.ID},
+	pager := asc.NewPager[asc.Build](client, func(ctx context.Context) (*asc.BuildsResponse, *asc.Response, error) {
+		return client.Builds.ListBuilds(ctx, &asc.ListBuildsQuery{
+			FilterApp: []string{app.ID},
+		})
+	})
+	for pager.Next(ctx) {
+		fmt.Println(*pager.Item().Attributes.Version)
 	}
-	for {
-		if cursor != "" {
-			params.Cursor = cursor
-		}
-		builds, _, err := client.Builds.ListBuilds(ctx, &params)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		for _, build := range builds.Data {
-			fmt.Println(*build.Attributes.Version)
-		}
-
-		if builds.Links.Next != nil {
-			cursor = builds.Links.Next.Cursor()
-		}
-		if cursor == "" {
-			break
-		}
+	if err := pager.Err(); err != nil {
+		log.Fatal(err)
 	}
 }
@@ -22,10 +22,8 @@ package main
 
 import (
 	"context"
-	"crypto/md5"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
 
@@ -40,6 +38,7 @@ var (
 	locale            = flag.String("locale", "", "Locale to add previews to")
 	previewTypeString = flag.String("previewtype", "", "Preview type")
 	previewFile       = flag.String("previewfile", "", "Path to a file to upload as a preview")
+	posterFrame       = flag.Duration("posterframe", 0, "Offset into the preview to use as its poster frame")
 )
 
 func main() {
@@ -139,64 +138,32 @@ func main() {
 		selectedPreviewSet = newPreviewSet.Data
 	}
 
-	// 8. Reserve an app preview in the selected app preview set.
-	//    Tell the API to create a preview before uploading the
-	//    preview data.
+	// 8. Upload the preview file to the selected app preview set.
+	//    UploadPreview reserves the preview, uploads each part of the file,
+	//    commits the reservation with the file's checksum, and waits for
+	//    App Store Connect to finish processing the preview.
 	file, err := os.Open(*previewFile)
 	if err != nil {
 		log.Fatalf("file could not be read: %s", err)
 	}
+	defer util.Close(file)
+
 	stat, err := file.Stat()
 	if err != nil {
 		log.Fatalf("file could not be read: %s", err)
 	}
-	fmt.Println("Reserving space for a new app preview.")
-	reservePreview, _, err := client.Apps.CreateAppPreview(ctx, file.Name(), stat.Size(), selectedPreviewSet.ID)
-	if err != nil {
-		fmt.Println(err)
-	}
-	preview := reservePreview.Data
-
-	// 9. Upload each part according to the returned upload operations.
-	//     The reservation returned uploadOperations, which instructs us how
-	//     to split the asset into parts. Upload each part individually.
-	//     Note: To speed up the process, upload multiple parts asynchronously
-	//     if you have the bandwidth.
-	uploadOperations := preview.Attributes.UploadOperations
-	fmt.Printf("Uploading %d preview components\n", len(uploadOperations))
-	err = client.Upload(ctx, uploadOperations, file)
-	if err != nil {
-		log.Fatalf("file could not be read: %s", err)
-	}
 
-	// 10. Commit the reservation and provide a checksum.
-	//     Committing tells App Store Connect the script is finished uploading parts.
-	//     App Store Connect uses the checksum to ensure the parts were uploaded
-	//     successfully.
-	fmt.Println("Commit the reservation")
-	previewURL := preview.Links.Self
-	checksum, err := md5Checksum(*previewFile)
-	if err != nil {
-		log.Fatalf("file checksum could not be calculated: %s", err)
+	var timeCode *string
+	if *posterFrame > 0 {
+		timeCode = asc.String(asc.PreviewFrameTimeCode(*posterFrame, 30))
 	}
 
-	client.Apps.CommitAppPreview(ctx, preview.ID, asc.Bool(true), &checksum, nil)
-
-	// Report success to the caller.
-	fmt.Printf("\nApp Preview successfully uploaded to:\n%s\nYou can verify success in App Store Connect or using the API.\n\n", previewURL.String())
-}
-
-func md5Checksum(file string) (string, error) {
-	f, err := os.Open(file)
+	fmt.Println("Uploading the app preview.")
+	preview, err := client.Apps.UploadPreview(ctx, selectedPreviewSet.ID, stat.Name(), file, stat.Size(), timeCode)
 	if err != nil {
-		return "", err
-	}
-	defer util.Close(f)
-
-	h := md5.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
+		log.Fatal(err)
 	}
 
-	return fmt.Sprintf("%x", h.Sum(nil)), nil
+	// Report success to the caller.
+	fmt.Printf("\nApp Preview successfully uploaded to:\n%s\nYou can verify success in App Store Connect or using the API.\n\n", preview.Links.Self.String())
 }
@@ -22,10 +22,8 @@ package main
 
 import (
 	"context"
-	"crypto/md5"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
 
@@ -139,64 +137,26 @@ func main() {
 		selectedScreenshotSet = newScreenshotSet.Data
 	}
 
-	// 8. Reserve an app screenshot in the selected app screenshot set.
-	//    Tell the API to create a screenshot before uploading the
-	//    screenshot data.
+	// 8. Upload the screenshot to the selected app screenshot set.
+	//    This reserves an app screenshot, uploads each part of the file according
+	//    to the returned upload operations, commits the reservation with the file's
+	//    MD5 checksum, and waits until App Store Connect has processed the asset.
+	//    If anything fails, the reservation is deleted.
 	file, err := os.Open(*screenshotFile)
 	if err != nil {
 		log.Fatalf("file could not be read: %s", err)
 	}
+	defer util.Close(file)
 	stat, err := file.Stat()
 	if err != nil {
 		log.Fatalf("file could not be read: %s", err)
 	}
-	fmt.Println("Reserving space for a new app screenshot.")
-	reserveScreenshot, _, err := client.Apps.CreateAppScreenshot(ctx, file.Name(), stat.Size(), selectedScreenshotSet.ID)
+	fmt.Println("Uploading the app screenshot.")
+	screenshot, err := client.Apps.UploadScreenshot(ctx, selectedScreenshotSet.ID, stat.Name(), file, stat.Size())
 	if err != nil {
-		fmt.Println(err)
-	}
-	screenshot := reserveScreenshot.Data
-
-	// 9. Upload each part according to the returned upload operations.
-	//     The reservation returned uploadOperations, which instructs us how
-	//     to split the asset into parts. Upload each part individually.
-	//     Note: To speed up the process, upload multiple parts asynchronously
-	//     if you have the bandwidth.
-	uploadOperations := screenshot.Attributes.UploadOperations
-	fmt.Printf("Uploading %d screenshot components\n", len(uploadOperations))
-	err = client.Upload(ctx, uploadOperations, file)
-	if err != nil {
-		log.Fatalf("file could not be read: %s", err)
+		log.Fatalf("screenshot could not be uploaded: %s", err)
 	}
 
-	// 10. Commit the reservation and provide a checksum.
-	//     Committing tells App Store Connect the script is finished uploading parts.
-	//     App Store Connect uses the checksum to ensure the parts were uploaded
-	//     successfully.
-	fmt.Println("Commit the reservation")
-	screenshotURL := screenshot.Links.Self
-	checksum, err := md5Checksum(*screenshotFile)
-	if err != nil {
-		log.Fatalf("file checksum could not be calculated: %s", err)
-	}
-
-	client.Apps.CommitAppScreenshot(ctx, screenshot.ID, asc.Bool(true), &checksum)
-
 	// Report success to the caller.
-	fmt.Printf("\nApp Screenshot successfully uploaded to:\n%s\nYou can verify success in App Store Connect or using the API.\n\n", screenshotURL.String())
-}
-
-func md5Checksum(file string) (string, error) {
-	f, err := os.Open(file)
-	if err != nil {
-		return "", err
-	}
-	defer util.Close(f)
-
-	h := md5.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
-	}
-
-	return fmt.Sprintf("%x", h.Sum(nil)), nil
+	fmt.Printf("\nApp Screenshot successfully uploaded to:\n%s\nYou can verify success in App Store Connect or using the API.\n\n", screenshot.Links.Self.String())
 }
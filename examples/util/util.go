@@ -38,10 +38,15 @@ var (
 	issuerID       = flag.String("iss", "", "issuer ID")
 	privateKey     = flag.String("privatekey", "", "private key used to sign authorization token")
 	privateKeyPath = flag.String("privatekeypath", "", "path to a private key used to sign authorization token")
+	profile        = flag.String("profile", "", "credentials profile to load when no key ID is given")
 )
 
-// TokenConfig creates the auth transport using the required information
+// TokenConfig creates the auth transport using the required information. Without a key ID,
+// the credentials are loaded from the environment or ~/.appstoreconnect for the given profile.
 func TokenConfig() (auth *asc.AuthTransport, err error) {
+	if *keyID == "" {
+		return asc.LoadAuthTransport(*profile)
+	}
 	var secret []byte
 	if *privateKey != "" {
 		secret = []byte(*privateKey)